@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"screensaver-ad-backend/internal/services"
+)
+
+// commandServices holds the services available to CLI subcommands
+type commandServices struct {
+	reconcile *services.ReconcileService
+}
+
+// runCommand executes a CLI subcommand instead of starting the server
+func runCommand(name string, args []string, svc commandServices) error {
+	switch name {
+	case "reconcile":
+		return runReconcile(args, svc.reconcile)
+	default:
+		return fmt.Errorf("unknown command %q (available: reconcile)", name)
+	}
+}
+
+// runReconcile compares storage with the database and prints the report as JSON.
+// It only reports unless -repair is given.
+func runReconcile(args []string, service *services.ReconcileService) error {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	repair := flags.Bool("repair", false, "repair discrepancies instead of only reporting them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	report, err := service.Run(!*repair)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"screensaver-ad-backend/internal/dispatch"
+)
+
+// DispatchConfig holds settings for sending tasks to the rendering worker
+type DispatchConfig struct {
+	// CallbackURL is the webhook URL workers report task events to
+	CallbackURL string
+	// URLExpiry is how long the input and template URLs in a job stay valid
+	URLExpiry time.Duration
+	// RetryInterval between resends of tasks whose dispatch failed; zero disables resending
+	RetryInterval time.Duration
+	// MaxAttempts bounds the dispatch attempts per task
+	MaxAttempts int
+}
+
+var (
+	Dispatch          DispatchConfig
+	DispatchTransport dispatch.Transport
+)
+
+// InitDispatch initializes the worker transport, selected by DISPATCH_TRANSPORT:
+// "none" (default) leaves tasks pending for an external poller and "http" posts jobs to
+// WORKER_URL
+func InitDispatch() error {
+	Dispatch = DispatchConfig{
+		CallbackURL:   os.Getenv("WORKER_CALLBACK_URL"),
+		URLExpiry:     getEnvDuration("DISPATCH_URL_EXPIRY", 6*time.Hour),
+		RetryInterval: getEnvDuration("DISPATCH_RETRY_INTERVAL", time.Minute),
+		MaxAttempts:   getEnvInt("DISPATCH_MAX_ATTEMPTS", 5),
+	}
+
+	transport := strings.ToLower(getEnv("DISPATCH_TRANSPORT", "none"))
+	switch transport {
+	case "none":
+		DispatchTransport = nil
+	case "http":
+		url := os.Getenv("WORKER_URL")
+		if url == "" {
+			return fmt.Errorf("DISPATCH_TRANSPORT is http but WORKER_URL is not set")
+		}
+		DispatchTransport = dispatch.NewHTTPTransport(url, os.Getenv("WORKER_API_KEY"), getEnvDuration("WORKER_TIMEOUT", 10*time.Second))
+	default:
+		return fmt.Errorf("unknown DISPATCH_TRANSPORT %q", transport)
+	}
+
+	log.Printf("Dispatch transport initialized: %s", transport)
+	return nil
+}
+
+// GetDispatchConfig returns the dispatch configuration
+func GetDispatchConfig() DispatchConfig {
+	return Dispatch
+}
+
+// GetDispatchTransport returns the worker transport, or nil when dispatch is disabled
+func GetDispatchTransport() dispatch.Transport {
+	return DispatchTransport
+}
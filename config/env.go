@@ -0,0 +1,77 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// getEnv returns the value of an environment variable or def when unset
+func getEnv(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// getEnvInt parses an integer environment variable, falling back to def
+func getEnvInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s=%q, using default %d", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// getEnvDuration parses a duration environment variable (e.g. "15m"), falling back to def
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s=%q, using default %s", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// getEnvIntList parses a comma separated list of positive integers, falling back to def
+func getEnvIntList(key string, def []int) []int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	var parsed []int
+	for _, part := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n <= 0 {
+			log.Printf("Warning: invalid %s=%q, using default %v", key, value, def)
+			return def
+		}
+		parsed = append(parsed, n)
+	}
+	return parsed
+}
+
+// getEnvFloat parses a floating point environment variable, falling back to def
+func getEnvFloat(key string, def float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Warning: invalid %s=%q, using default %g", key, value, def)
+		return def
+	}
+	return parsed
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScreenProfile is a target screen for which image renditions are generated
+type ScreenProfile struct {
+	Name   string
+	Width  int
+	Height int
+}
+
+// MediaConfig holds settings for processing uploaded media
+type MediaConfig struct {
+	// ThumbnailSizes are the longest edges in pixels of the thumbnails generated for images
+	ThumbnailSizes []int
+	// ThumbnailQuality is the JPEG quality of thumbnails (1-100)
+	ThumbnailQuality int
+	// ReencodeQuality is the JPEG quality used when an upload has to be rotated upright
+	ReencodeQuality int
+	// ScreenProfiles are the screens image renditions are cropped and resized for
+	ScreenProfiles []ScreenProfile
+	// RenditionQuality is the JPEG quality of renditions (1-100)
+	RenditionQuality int
+	// ProcessInterval is how often new uploads are processed in the background; zero
+	// processes them within the upload request instead
+	ProcessInterval time.Duration
+}
+
+var Media MediaConfig
+
+// defaultScreenProfiles covers the landscape 1080p, 4K and portrait kiosk screens
+const defaultScreenProfiles = "landscape-1080p:1920x1080,landscape-4k:3840x2160,portrait-1080p:1080x1920"
+
+// InitMedia loads the media processing configuration from the environment
+func InitMedia() {
+	Media = MediaConfig{
+		ThumbnailSizes:   getEnvIntList("THUMBNAIL_SIZES", []int{160, 480, 1280}),
+		ThumbnailQuality: getEnvInt("THUMBNAIL_QUALITY", 85),
+		ReencodeQuality:  getEnvInt("IMAGE_REENCODE_QUALITY", 92),
+		RenditionQuality: getEnvInt("RENDITION_QUALITY", 90),
+		ProcessInterval:  getEnvDuration("MEDIA_PROCESS_INTERVAL", 5*time.Second),
+	}
+	sort.Ints(Media.ThumbnailSizes)
+
+	profiles, err := parseScreenProfiles(getEnv("SCREEN_PROFILES", defaultScreenProfiles))
+	if err != nil {
+		log.Printf("Warning: invalid SCREEN_PROFILES=%q (%v), using defaults", os.Getenv("SCREEN_PROFILES"), err)
+		profiles, _ = parseScreenProfiles(defaultScreenProfiles)
+	}
+	Media.ScreenProfiles = profiles
+}
+
+// GetMediaConfig returns the media processing configuration
+func GetMediaConfig() MediaConfig {
+	return Media
+}
+
+// Profile returns the screen profile with the given name
+func (c MediaConfig) Profile(name string) (ScreenProfile, bool) {
+	for _, profile := range c.ScreenProfiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return ScreenProfile{}, false
+}
+
+// parseScreenProfiles parses a comma separated list of name:WIDTHxHEIGHT entries
+func parseScreenProfiles(value string) ([]ScreenProfile, error) {
+	var profiles []ScreenProfile
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, size, ok := strings.Cut(entry, ":")
+		w, h, ok2 := strings.Cut(size, "x")
+		if !ok || !ok2 || name == "" {
+			return nil, strconv.ErrSyntax
+		}
+		width, err := strconv.Atoi(w)
+		if err != nil || width <= 0 {
+			return nil, strconv.ErrSyntax
+		}
+		height, err := strconv.Atoi(h)
+		if err != nil || height <= 0 {
+			return nil, strconv.ErrSyntax
+		}
+		profiles = append(profiles, ScreenProfile{Name: name, Width: width, Height: height})
+	}
+	return profiles, nil
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"screensaver-ad-backend/internal/moderation"
+)
+
+var Moderator moderation.Moderator
+
+// InitModerator initializes the content moderation hook, selected by MODERATOR:
+// "none" (default) skips moderation, "local" applies the MODERATION_* rules and
+// "http" calls the classifier at MODERATION_URL
+func InitModerator() error {
+	mode := strings.ToLower(getEnv("MODERATOR", "none"))
+
+	switch mode {
+	case "none":
+		Moderator = nil
+	case "local":
+		banned, err := loadBannedHashes()
+		if err != nil {
+			return err
+		}
+		Moderator = moderation.NewLocalModerator(moderation.LocalRules{
+			MaxFileSize:  int64(getEnvInt("MODERATION_MAX_FILE_SIZE_MB", 0)) << 20,
+			BannedHashes: banned,
+			MinWidth:     getEnvInt("MODERATION_MIN_WIDTH", 0),
+			MinHeight:    getEnvInt("MODERATION_MIN_HEIGHT", 0),
+			MaxWidth:     getEnvInt("MODERATION_MAX_WIDTH", 0),
+			MaxHeight:    getEnvInt("MODERATION_MAX_HEIGHT", 0),
+		})
+	case "http":
+		url := os.Getenv("MODERATION_URL")
+		if url == "" {
+			return fmt.Errorf("MODERATOR is http but MODERATION_URL is not set")
+		}
+		Moderator = moderation.NewHTTPModerator(url, os.Getenv("MODERATION_API_KEY"), getEnvDuration("MODERATION_TIMEOUT", 30*time.Second))
+	default:
+		return fmt.Errorf("unknown MODERATOR %q", mode)
+	}
+
+	log.Printf("Moderator initialized: %s", mode)
+	return nil
+}
+
+// loadBannedHashes reads the SHA-256 hashes listed in MODERATION_BANNED_HASHES (comma
+// separated) and in the file MODERATION_BANNED_HASHES_FILE (one per line, # comments)
+func loadBannedHashes() (map[string]bool, error) {
+	banned := map[string]bool{}
+	for _, hash := range strings.Split(os.Getenv("MODERATION_BANNED_HASHES"), ",") {
+		if hash = strings.ToLower(strings.TrimSpace(hash)); hash != "" {
+			banned[hash] = true
+		}
+	}
+
+	path := os.Getenv("MODERATION_BANNED_HASHES_FILE")
+	if path == "" {
+		return banned, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open banned hashes file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		banned[strings.ToLower(line)] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read banned hashes file: %w", err)
+	}
+	return banned, nil
+}
+
+// GetModerator returns the configured moderator, or nil when moderation is disabled
+func GetModerator() moderation.Moderator {
+	return Moderator
+}
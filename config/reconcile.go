@@ -0,0 +1,29 @@
+package config
+
+import "time"
+
+// ReconcileConfig holds settings for the storage/database reconciliation job
+type ReconcileConfig struct {
+	// Interval between scheduled runs; zero disables the schedule
+	Interval time.Duration
+	// GracePeriod protects recently written objects that may belong to in-flight uploads
+	GracePeriod time.Duration
+	// Repair makes scheduled runs fix discrepancies instead of only reporting them
+	Repair bool
+}
+
+var Reconcile ReconcileConfig
+
+// InitReconcile loads the reconciliation configuration from the environment
+func InitReconcile() {
+	Reconcile = ReconcileConfig{
+		Interval:    getEnvDuration("RECONCILE_INTERVAL", 0),
+		GracePeriod: getEnvDuration("RECONCILE_GRACE_PERIOD", time.Hour),
+		Repair:      getEnv("RECONCILE_REPAIR", "false") == "true",
+	}
+}
+
+// GetReconcileConfig returns the reconciliation configuration
+func GetReconcileConfig() ReconcileConfig {
+	return Reconcile
+}
@@ -0,0 +1,26 @@
+package config
+
+import "time"
+
+// RetentionConfig holds settings for purging soft-deleted assets
+type RetentionConfig struct {
+	// Period is how long a deleted asset can still be restored before it is purged
+	Period time.Duration
+	// PurgeInterval is how often the purge worker runs; zero disables it
+	PurgeInterval time.Duration
+}
+
+var Retention RetentionConfig
+
+// InitRetention loads the retention configuration from the environment
+func InitRetention() {
+	Retention = RetentionConfig{
+		Period:        getEnvDuration("ASSET_RETENTION_PERIOD", 30*24*time.Hour),
+		PurgeInterval: getEnvDuration("ASSET_PURGE_INTERVAL", time.Hour),
+	}
+}
+
+// GetRetentionConfig returns the retention configuration
+func GetRetentionConfig() RetentionConfig {
+	return Retention
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"screensaver-ad-backend/internal/storage"
+)
+
+var (
+	Storage storage.Storage
+	// OutputPrefix is the key prefix under which processed outputs are written
+	OutputPrefix string
+)
+
+// InitStorage initializes the object storage backend selected by STORAGE_BACKEND.
+// When unset, S3 is used if it is configured and the local filesystem otherwise.
+// InitS3 and InitUpload must be called first.
+func InitStorage() error {
+	OutputPrefix = strings.Trim(getEnv("STORAGE_OUTPUT_PREFIX", "output"), "/")
+
+	backend := strings.ToLower(os.Getenv("STORAGE_BACKEND"))
+	if backend == "" {
+		if S3Client != nil {
+			backend = "s3"
+		} else {
+			backend = "local"
+		}
+	}
+
+	switch backend {
+	case "s3":
+		if S3Client == nil {
+			return fmt.Errorf("STORAGE_BACKEND is s3 but AWS credentials are not configured")
+		}
+		Storage = storage.NewS3Storage(S3Client, S3Bucket, Upload.PartSize, Upload.Concurrency)
+	case "local":
+		dir := getEnv("LOCAL_STORAGE_DIR", "./data/storage")
+		baseURL := getEnv("LOCAL_STORAGE_BASE_URL", "http://localhost:8080/storage")
+		secret := []byte(os.Getenv("LOCAL_STORAGE_SECRET"))
+		if len(secret) == 0 {
+			// Signed URLs will not survive a restart without a configured secret
+			log.Println("Warning: LOCAL_STORAGE_SECRET not set, using a random signing key")
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				return err
+			}
+		}
+
+		local, err := storage.NewLocalStorage(dir, baseURL, secret)
+		if err != nil {
+			return err
+		}
+		Storage = local
+	default:
+		return fmt.Errorf("unknown STORAGE_BACKEND %q", backend)
+	}
+
+	log.Printf("Storage backend initialized: %s", backend)
+	return nil
+}
+
+// GetStorage returns the configured storage backend
+func GetStorage() storage.Storage {
+	return Storage
+}
+
+// GetOutputPrefix returns the key prefix of processed outputs
+func GetOutputPrefix() string {
+	return OutputPrefix
+}
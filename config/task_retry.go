@@ -0,0 +1,35 @@
+package config
+
+import "time"
+
+// TaskRetryConfig holds the default retry policy for failed tasks and the retry scheduler settings
+type TaskRetryConfig struct {
+	// MaxAttempts is the total number of attempts per task, including the first one
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry; it doubles with every attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts
+	MaxDelay time.Duration
+	// Jitter randomizes each delay by up to this fraction
+	Jitter float64
+	// Interval is how often the scheduler re-issues due retries; zero disables it
+	Interval time.Duration
+}
+
+var TaskRetry TaskRetryConfig
+
+// InitTaskRetry loads the task retry configuration from the environment
+func InitTaskRetry() {
+	TaskRetry = TaskRetryConfig{
+		MaxAttempts: getEnvInt("TASK_MAX_ATTEMPTS", 3),
+		BaseDelay:   getEnvDuration("TASK_RETRY_BASE_DELAY", 30*time.Second),
+		MaxDelay:    getEnvDuration("TASK_RETRY_MAX_DELAY", 30*time.Minute),
+		Jitter:      getEnvFloat("TASK_RETRY_JITTER", 0.2),
+		Interval:    getEnvDuration("TASK_RETRY_INTERVAL", 30*time.Second),
+	}
+}
+
+// GetTaskRetryConfig returns the task retry configuration
+func GetTaskRetryConfig() TaskRetryConfig {
+	return TaskRetry
+}
@@ -0,0 +1,26 @@
+package config
+
+import "time"
+
+// TaskTimeoutConfig holds the default task timeout and the stuck-task sweeper settings
+type TaskTimeoutConfig struct {
+	// Timeout is how long a task may take after it is queued; zero means no default timeout
+	Timeout time.Duration
+	// SweepInterval is how often the sweeper looks for timed-out tasks; zero disables it
+	SweepInterval time.Duration
+}
+
+var TaskTimeout TaskTimeoutConfig
+
+// InitTaskTimeout loads the task timeout configuration from the environment
+func InitTaskTimeout() {
+	TaskTimeout = TaskTimeoutConfig{
+		Timeout:       getEnvDuration("TASK_TIMEOUT", time.Hour),
+		SweepInterval: getEnvDuration("TASK_SWEEP_INTERVAL", time.Minute),
+	}
+}
+
+// GetTaskTimeoutConfig returns the task timeout configuration
+func GetTaskTimeoutConfig() TaskTimeoutConfig {
+	return TaskTimeout
+}
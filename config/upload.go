@@ -0,0 +1,52 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// UploadConfig holds settings for asset uploads
+type UploadConfig struct {
+	// PresignExpiry is how long a presigned upload URL stays valid
+	PresignExpiry time.Duration
+	// PendingCleanupInterval is how often expired pending and resumable uploads are removed
+	PendingCleanupInterval time.Duration
+	// ResumableExpiry is how long an unfinished resumable upload is kept
+	ResumableExpiry time.Duration
+	// PartSize is the size of each part streamed to storage
+	PartSize int64
+	// Concurrency is the number of parts uploaded in parallel
+	Concurrency int
+	// MaxImageSize is the largest accepted image in bytes
+	MaxImageSize int64
+	// MaxVideoSize is the largest accepted video in bytes
+	MaxVideoSize int64
+}
+
+var Upload UploadConfig
+
+// InitUpload loads the upload configuration from the environment
+func InitUpload() {
+	Upload = UploadConfig{
+		PresignExpiry:          getEnvDuration("UPLOAD_PRESIGN_EXPIRY", 15*time.Minute),
+		PendingCleanupInterval: getEnvDuration("UPLOAD_PENDING_CLEANUP_INTERVAL", 10*time.Minute),
+		ResumableExpiry:        getEnvDuration("UPLOAD_RESUMABLE_EXPIRY", 24*time.Hour),
+		PartSize:               int64(getEnvInt("UPLOAD_PART_SIZE_MB", 16)) << 20,
+		Concurrency:            getEnvInt("UPLOAD_CONCURRENCY", 4),
+		MaxImageSize:           int64(getEnvInt("UPLOAD_MAX_IMAGE_SIZE_MB", 50)) << 20,
+		MaxVideoSize:           int64(getEnvInt("UPLOAD_MAX_VIDEO_SIZE_MB", 2048)) << 20,
+	}
+}
+
+// GetUploadConfig returns the upload configuration
+func GetUploadConfig() UploadConfig {
+	return Upload
+}
+
+// MaxSizeFor returns the maximum upload size in bytes for a content type
+func (c UploadConfig) MaxSizeFor(contentType string) int64 {
+	if strings.HasPrefix(contentType, "video/") {
+		return c.MaxVideoSize
+	}
+	return c.MaxImageSize
+}
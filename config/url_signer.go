@@ -0,0 +1,71 @@
+package config
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"screensaver-ad-backend/internal/storage"
+
+	"github.com/aws/aws-sdk-go/service/cloudfront/sign"
+)
+
+var URLSigner storage.URLSigner
+
+// InitURLSigner initializes the signer used for download URLs, selected by URL_SIGNER:
+// "presign" (default) uses the storage backend's presigned URLs and "cloudfront" signs
+// CDN URLs with a CloudFront key pair. InitStorage must be called first.
+func InitURLSigner() error {
+	signer := strings.ToLower(getEnv("URL_SIGNER", "presign"))
+
+	switch signer {
+	case "presign":
+		URLSigner = storage.NewPresignSigner(Storage)
+	case "cloudfront":
+		domain := os.Getenv("CDN_DOMAIN")
+		keyPairID := os.Getenv("CLOUDFRONT_KEY_PAIR_ID")
+		if domain == "" || keyPairID == "" {
+			return fmt.Errorf("URL_SIGNER is cloudfront but CDN_DOMAIN or CLOUDFRONT_KEY_PAIR_ID is not set")
+		}
+
+		key, err := loadCloudFrontKey()
+		if err != nil {
+			return err
+		}
+		URLSigner = storage.NewCloudFrontSigner(domain, os.Getenv("CDN_OUTPUT_DOMAIN"), OutputPrefix, keyPairID, key)
+	default:
+		return fmt.Errorf("unknown URL_SIGNER %q", signer)
+	}
+
+	log.Printf("URL signer initialized: %s", signer)
+	return nil
+}
+
+// loadCloudFrontKey reads the PEM encoded RSA key from CLOUDFRONT_PRIVATE_KEY_PATH,
+// or from CLOUDFRONT_PRIVATE_KEY where newlines may be written as \n
+func loadCloudFrontKey() (*rsa.PrivateKey, error) {
+	if path := os.Getenv("CLOUDFRONT_PRIVATE_KEY_PATH"); path != "" {
+		key, err := sign.LoadPEMPrivKeyFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CloudFront private key: %w", err)
+		}
+		return key, nil
+	}
+
+	pem := strings.ReplaceAll(os.Getenv("CLOUDFRONT_PRIVATE_KEY"), `\n`, "\n")
+	if pem == "" {
+		return nil, fmt.Errorf("URL_SIGNER is cloudfront but neither CLOUDFRONT_PRIVATE_KEY_PATH nor CLOUDFRONT_PRIVATE_KEY is set")
+	}
+	key, err := sign.LoadPEMPrivKey(strings.NewReader(pem))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CloudFront private key: %w", err)
+	}
+	return key, nil
+}
+
+// GetURLSigner returns the configured download URL signer
+func GetURLSigner() storage.URLSigner {
+	return URLSigner
+}
@@ -71,7 +71,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Upload a new asset file with metadata",
+                "description": "Upload a new asset file with metadata. The body is streamed to storage, so the name field must be sent before the file.",
                 "consumes": [
                     "multipart/form-data"
                 ],
@@ -98,6 +98,13 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "Identical file already exists, existing asset returned with duplicate_of",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "201": {
                         "description": "Asset created successfully",
                         "schema": {
@@ -112,6 +119,67 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "413": {
+                        "description": "File too large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "415": {
+                        "description": "File content is not a supported type or does not match the declared type (code: unsupported_content_type, content_type_mismatch, corrupt_image, corrupt_container)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/trash": {
+            "get": {
+                "description": "Get a paginated list of deleted assets that can still be restored before they are purged",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "List deleted assets",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of deleted assets",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -122,6 +190,69 @@ const docTemplate = `{
                 }
             }
         },
+        "/assets/uploads": {
+            "post": {
+                "description": "Create a pending asset and return a presigned PUT URL for uploading the file directly to storage",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Start a direct upload",
+                "parameters": [
+                    {
+                        "description": "Upload request",
+                        "name": "upload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "content_type": {
+                                    "type": "string"
+                                },
+                                "file_name": {
+                                    "type": "string"
+                                },
+                                "file_size": {
+                                    "type": "integer"
+                                },
+                                "name": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Pending asset and upload URL",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "File too large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
         "/assets/{id}": {
             "get": {
                 "description": "Retrieve a specific asset by its ID",
@@ -266,9 +397,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/assets/{id}/status": {
-            "patch": {
-                "description": "Update the status of an asset (uploaded, processed, upload_failed, process_failed) and the output url",
+        "/assets/{id}/complete": {
+            "post": {
+                "description": "Verify that the file of a pending asset reached storage and mark the asset as uploaded",
                 "consumes": [
                     "application/json"
                 ],
@@ -278,7 +409,7 @@ const docTemplate = `{
                 "tags": [
                     "assets"
                 ],
-                "summary": "Update asset status and the output url",
+                "summary": "Complete a direct upload",
                 "parameters": [
                     {
                         "type": "integer",
@@ -286,42 +417,32 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Status update request",
-                        "name": "status",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "output_s3_key": {
-                                    "type": "string"
-                                },
-                                "status": {
-                                    "type": "string"
-                                }
-                            }
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Asset status updated successfully",
+                        "description": "Upload completed, or existing asset returned with duplicate_of when the content is identical",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Upload could not be verified",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "404": {
+                        "description": "Asset not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "415": {
+                        "description": "File content is not a supported type or does not match the declared type, or the image or video container is corrupt",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -330,19 +451,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/assets/{id}/url": {
+        "/assets/{id}/content": {
             "get": {
-                "description": "Generate presigned URLs for both input and output asset files",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Stream the input or output file of an asset through the server. Supports Range requests (206 Partial Content), ETag and Last-Modified for players that cannot reach storage URLs directly.",
                 "produces": [
-                    "application/json"
+                    "application/octet-stream"
                 ],
                 "tags": [
                     "assets"
                 ],
-                "summary": "Get asset URLs",
+                "summary": "Stream an asset file",
                 "parameters": [
                     {
                         "type": "integer",
@@ -352,28 +470,59 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "default": 60,
-                        "description": "URL expiration time in minutes",
-                        "name": "expiration",
+                        "enum": [
+                            "input",
+                            "output"
+                        ],
+                        "type": "string",
+                        "default": "input",
+                        "description": "File to stream",
+                        "name": "variant",
                         "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Send as attachment instead of inline",
+                        "name": "download",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Byte range, e.g. bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Presigned URLs for input and output files",
+                        "description": "Full file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Requested byte range",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID or variant",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Invalid ID",
+                    "404": {
+                        "description": "Asset or file not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
+                    "416": {
+                        "description": "Range not satisfiable"
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -384,9 +533,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/tasks": {
-            "post": {
-                "description": "Create a new task if no record exists with same asset and template IDs",
+        "/assets/{id}/focal-point": {
+            "put": {
+                "description": "Set the point (x and y from 0 to 1, from the top left) that screen renditions are cropped around and regenerate the renditions. Send nulls to fall back to automatic subject detection.",
                 "consumes": [
                     "application/json"
                 ],
@@ -394,55 +543,51 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tasks"
+                    "assets"
                 ],
-                "summary": "Create a new task",
+                "summary": "Set the focal point of an image asset",
                 "parameters": [
                     {
-                        "description": "Task object",
-                        "name": "task",
+                        "type": "integer",
+                        "description": "Asset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Focal point",
+                        "name": "focal_point",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "asset_id": {
-                                    "type": "integer"
-                                },
-                                "metadata": {
-                                    "type": "object"
+                                "x": {
+                                    "type": "number"
                                 },
-                                "template_id": {
-                                    "type": "integer"
+                                "y": {
+                                    "type": "number"
                                 }
                             }
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Task created successfully",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "202": {
-                        "description": "Task already exists",
+                    "200": {
+                        "description": "Asset with regenerated renditions",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.Asset"
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Invalid focal point or not an image",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "404": {
+                        "description": "Asset not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -451,9 +596,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/templates": {
-            "get": {
-                "description": "Get a list of all templates with presigned URLs",
+        "/assets/{id}/restore": {
+            "post": {
+                "description": "Undo the deletion of an asset that is still inside the retention period",
                 "consumes": [
                     "application/json"
                 ],
@@ -461,18 +606,44 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "templates"
+                    "assets"
+                ],
+                "summary": "Restore a deleted asset",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Asset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "List all templates",
                 "responses": {
                     "200": {
-                        "description": "List of templates with URLs",
+                        "description": "Restored asset",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object",
-                                "additionalProperties": true
-                            }
+                            "$ref": "#/definitions/models.Asset"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Deleted asset not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "410": {
+                        "description": "Retention period has passed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
@@ -483,45 +654,128 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Upload a template video file with a name",
+            }
+        },
+        "/assets/{id}/review": {
+            "put": {
+                "description": "Override the moderation verdict after a manual review, e.g. to approve or reject assets marked needs_review. Rejected assets cannot be used in tasks.",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "templates"
+                    "assets"
                 ],
-                "summary": "Upload a new template",
+                "summary": "Review the moderation verdict of an asset",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Template name",
-                        "name": "name",
-                        "in": "formData",
+                        "type": "integer",
+                        "description": "Asset ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "file",
-                        "description": "Template video file",
-                        "name": "file",
-                        "in": "formData",
+                        "description": "Verdict (approve, reject or needs_review) and reason",
+                        "name": "review",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "reason": {
+                                    "type": "string"
+                                },
+                                "verdict": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reviewed asset",
+                        "schema": {
+                            "$ref": "#/definitions/models.Asset"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID or verdict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Asset not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{id}/similar": {
+            "get": {
+                "description": "List image assets whose perceptual hash (dHash) is within a Hamming distance of the asset's hash, closest first. Finds re-exported, re-compressed or resized copies that exact-hash deduplication misses.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Find near-duplicate assets",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Asset ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Maximum Hamming distance (0-64)",
+                        "name": "threshold",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Template uploaded successfully",
+                        "description": "Similar assets with their distance",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Invalid ID or threshold",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Asset not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Asset has no perceptual hash",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -537,9 +791,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/webhook": {
-            "post": {
-                "description": "Process webhook events with flexible payload structure",
+        "/assets/{id}/status": {
+            "patch": {
+                "description": "Update the status of an asset (uploaded, processed, upload_failed, process_failed) and the output url",
                 "consumes": [
                     "application/json"
                 ],
@@ -547,23 +801,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "webhook"
+                    "assets"
                 ],
-                "summary": "Handle webhook events",
+                "summary": "Update asset status and the output url",
                 "parameters": [
                     {
-                        "description": "Webhook event with payload",
-                        "name": "event",
+                        "type": "integer",
+                        "description": "Asset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Status update request",
+                        "name": "status",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "event_type": {
+                                "output_s3_key": {
                                     "type": "string"
                                 },
-                                "payload": {
-                                    "type": "object"
+                                "status": {
+                                    "type": "string"
                                 }
                             }
                         }
@@ -571,7 +832,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Event processed successfully",
+                        "description": "Asset status updated successfully",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -593,79 +854,2017 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "gorm.DeletedAt": {
-            "type": "object",
-            "properties": {
-                "time": {
-                    "type": "string"
-                },
-                "valid": {
-                    "description": "Valid is true if Time is not NULL",
-                    "type": "boolean"
-                }
-            }
         },
-        "models.Asset": {
-            "type": "object",
-            "properties": {
-                "content_type": {
-                    "type": "string"
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "deleted_at": {
-                    "$ref": "#/definitions/gorm.DeletedAt"
-                },
-                "file_name": {
-                    "type": "string"
-                },
-                "file_size": {
-                    "type": "integer"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "output_s3_key": {
-                    "type": "string"
-                },
-                "processed_at": {
-                    "type": "string"
-                },
-                "s3_bucket": {
-                    "type": "string"
-                },
-                "s3_key": {
-                    "type": "string"
-                },
-                "status": {
-                    "$ref": "#/definitions/models.AssetStatus"
-                },
-                "updated_at": {
-                    "type": "string"
-                },
-                "uploaded_at": {
-                    "type": "string"
+        "/assets/{id}/tasks": {
+            "get": {
+                "description": "Get a paginated list of the tasks that use an asset, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "List the tasks of an asset",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Asset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated statuses",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before (RFC 3339 or YYYY-MM-DD)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of tasks with pagination info",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID or filter",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Asset not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
                 }
             }
         },
-        "models.AssetStatus": {
-            "type": "string",
-            "enum": [
-                "uploaded",
-                "processed",
-                "process_failed",
-                "upload_failed"
-            ],
-            "x-enum-varnames": [
-                "AssetStatusUploaded",
-                "AssetStatusProcessed",
-                "AssetStatusProcessFailed",
-                "AssetStatusUploadFailed"
-            ]
+        "/assets/{id}/url": {
+            "get": {
+                "description": "Generate signed URLs (storage presigned or CDN, see URL_SIGNER) for both input and output asset files, the thumbnails and optionally the rendition for a screen profile",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Get asset URLs",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Asset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 60,
+                        "description": "URL expiration time in minutes",
+                        "name": "expiration",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Screen profile whose rendition URL is returned, e.g. landscape-1080p",
+                        "name": "profile",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Signed URLs for input and output files",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID or unknown profile",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Asset or rendition not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks": {
+            "get": {
+                "description": "Get a paginated list of tasks with their asset and template, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "List tasks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated statuses (pending, queued, running, succeeded, failed, cancelled, retry_scheduled, timed_out)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Asset ID",
+                        "name": "asset_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "template_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before (RFC 3339 or YYYY-MM-DD)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of tasks with pagination info",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new task if no record exists with same asset and template IDs. The asset must be uploaded or processed and meet the template requirements (media types, aspect ratios, duration bounds); otherwise every violated rule is listed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Create a new task",
+                "parameters": [
+                    {
+                        "description": "Task object",
+                        "name": "task",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "asset_id": {
+                                    "type": "integer"
+                                },
+                                "metadata": {
+                                    "type": "object"
+                                },
+                                "template_id": {
+                                    "type": "integer"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Task created successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "202": {
+                        "description": "Task already exists (task_id names it for a re-run)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Asset or template not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Asset is not compatible with the template (violations: [{rule, message}])",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/batch": {
+            "post": {
+                "description": "Create the tasks for many asset and template pairs in one transaction. Without cartesian, asset_ids and template_ids are paired by position; with cartesian, every asset is paired with every template (at most 1000 pairs). Each pair is reported as created, existing or invalid (unknown asset or template, or not compatible, with the violations). New tasks are dispatched to the worker. Use the returned batch ID to follow its completion.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Create tasks in a batch",
+                "parameters": [
+                    {
+                        "description": "Batch request",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "asset_ids": {
+                                    "type": "array",
+                                    "items": {
+                                        "type": "integer"
+                                    }
+                                },
+                                "cartesian": {
+                                    "type": "boolean"
+                                },
+                                "metadata": {
+                                    "type": "object"
+                                },
+                                "template_ids": {
+                                    "type": "array",
+                                    "items": {
+                                        "type": "integer"
+                                    }
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Batch with per-item results",
+                        "schema": {
+                            "$ref": "#/definitions/models.TaskBatch"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/batch/{id}": {
+            "get": {
+                "description": "Get a batch with its items, the current status of each item's task, the number of tasks per status and whether every task has finished (succeeded, failed or cancelled)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Get a task batch",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Batch ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.TaskBatchProgress"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Batch not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/dead-letter": {
+            "get": {
+                "description": "Get a paginated list of tasks the worker did not finish within their template's timeout, newest first. Requeue them with POST /tasks/{id}/requeue.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "List timed-out tasks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of tasks with pagination info",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}": {
+            "get": {
+                "description": "Get a task with its asset and template",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Get a task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/cancel": {
+            "post": {
+                "description": "Cancel a task that has not finished (pending, queued, running, retry_scheduled or timed_out). Later worker events for the task, including processed, are ignored.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Cancel a task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Cancelled task",
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Task has already finished",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/requeue": {
+            "post": {
+                "description": "Move a timed_out task back to pending with a fresh retry budget and dispatch it to the worker again",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Requeue a timed-out task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Requeued task",
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Task, asset or template not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Task is not timed out",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/rerun": {
+            "post": {
+                "description": "Start a new run of a finished task (succeeded, failed or cancelled), e.g. after fixing its template. The finished run is kept in the task's runs history with its output key and metadata, and the new run renders to a new output key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Re-run a task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Task with the new run",
+                        "schema": {
+                            "$ref": "#/definitions/models.Task"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Task, asset or template not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Task has not finished",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Asset is no longer compatible with the template",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/templates": {
+            "get": {
+                "description": "Get a list of all templates with signed URLs",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "List all templates",
+                "responses": {
+                    "200": {
+                        "description": "List of templates with URLs",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "additionalProperties": true
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Upload a template video file with a name. The body is streamed to storage, so the name field must be sent before the file.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Upload a new template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Template name",
+                        "name": "name",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Template video file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Template uploaded successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "File too large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "415": {
+                        "description": "File content is not a supported video or does not match the declared type (code: unsupported_content_type, content_type_mismatch, corrupt_container)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/templates/{id}/requirements": {
+            "put": {
+                "description": "Replace the requirements an asset must meet before a task can combine it with the template. Media types are exact content types or wildcards such as image/*, aspect ratios are width:height with 1% tolerance and duration bounds (seconds) apply to video assets. Omitted fields accept everything.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Set template requirements",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Template requirements",
+                        "name": "requirements",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "accepted_media_types": {
+                                    "type": "array",
+                                    "items": {
+                                        "type": "string"
+                                    }
+                                },
+                                "aspect_ratios": {
+                                    "type": "array",
+                                    "items": {
+                                        "type": "string"
+                                    }
+                                },
+                                "max_duration": {
+                                    "type": "number"
+                                },
+                                "min_duration": {
+                                    "type": "number"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Requirements updated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID or requirements",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Template not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/templates/{id}/retry-policy": {
+            "put": {
+                "description": "Replace how failed tasks of the template are retried: total attempts, delay before the first retry (doubling per attempt), maximum delay (seconds) and jitter (fraction 0-1). Omitted fields use the TASK_RETRY_* defaults.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Set the retry policy of a template",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Retry policy",
+                        "name": "policy",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "base_delay": {
+                                    "type": "number"
+                                },
+                                "jitter": {
+                                    "type": "number"
+                                },
+                                "max_attempts": {
+                                    "type": "integer"
+                                },
+                                "max_delay": {
+                                    "type": "number"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Retry policy updated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID or policy",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Template not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/templates/{id}/tasks": {
+            "get": {
+                "description": "Get a paginated list of the tasks that use a template, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "List the tasks of a template",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated statuses",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before (RFC 3339 or YYYY-MM-DD)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of tasks with pagination info",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID or filter",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Template not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/templates/{id}/timeout": {
+            "put": {
+                "description": "Set how long tasks of the template may take after they are queued, in seconds, before the sweeper marks them timed_out. A null task_timeout restores the TASK_TIMEOUT default.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Set the task timeout of a template",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Task timeout",
+                        "name": "timeout",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "task_timeout": {
+                                    "type": "number"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Task timeout updated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID or timeout",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Template not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/tus": {
+            "post": {
+                "description": "Start a tus upload. Upload-Metadata must contain filename and filetype and may contain name.",
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Create a resumable upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "1.0.0",
+                        "description": "tus protocol version",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Total size of the file in bytes",
+                        "name": "Upload-Length",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated base64 encoded metadata (filename, filetype, name)",
+                        "name": "Upload-Metadata",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Upload created, see Location header"
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "File too large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "options": {
+                "description": "Return the supported tus version, extensions and maximum upload size",
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Discover tus capabilities",
+                "responses": {
+                    "204": {
+                        "description": "Capabilities returned in headers"
+                    }
+                }
+            }
+        },
+        "/uploads/tus/{id}": {
+            "delete": {
+                "description": "Discard an upload and the chunks received so far",
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Terminate a resumable upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "1.0.0",
+                        "description": "tus protocol version",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Upload terminated"
+                    },
+                    "404": {
+                        "description": "Upload not found"
+                    }
+                }
+            },
+            "head": {
+                "description": "Return how many bytes of the upload have been received",
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Get the offset of a resumable upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "1.0.0",
+                        "description": "tus protocol version",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Offset returned in the Upload-Offset header"
+                    },
+                    "404": {
+                        "description": "Upload not found"
+                    }
+                }
+            },
+            "patch": {
+                "description": "Append bytes at Upload-Offset. The asset is created once the last byte is received and its ID is returned in the Upload-Asset-Id header.",
+                "consumes": [
+                    "application/offset+octet-stream"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Upload a chunk",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "1.0.0",
+                        "description": "tus protocol version",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset the chunk starts at",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Chunk stored, new offset in the Upload-Offset header"
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Upload not found"
+                    },
+                    "409": {
+                        "description": "Offset mismatch",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "410": {
+                        "description": "Upload failed after the last chunk",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported content type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/webhook": {
+            "post": {
+                "description": "Process worker events for a task (payload.task_id). Events drive the task status: queued, started (running), processed (succeeded; payload.s3_key sets the asset output) and failed (payload.error is recorded; the task is retried per the template retry policy). Skipped events are filled in, repeated events are ignored and other event types are accepted without effect.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Handle webhook events",
+                "parameters": [
+                    {
+                        "description": "Webhook event with payload",
+                        "name": "event",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "event_type": {
+                                    "type": "string"
+                                },
+                                "payload": {
+                                    "type": "object"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event processed successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Event not allowed in the current task status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "gorm.DeletedAt": {
+            "type": "object",
+            "properties": {
+                "time": {
+                    "type": "string"
+                },
+                "valid": {
+                    "description": "Valid is true if Time is not NULL",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.Asset": {
+            "type": "object",
+            "properties": {
+                "blurhash": {
+                    "type": "string"
+                },
+                "color_model": {
+                    "type": "string"
+                },
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "duplicate_of": {
+                    "description": "DuplicateOf is set when an upload matched this existing asset instead of creating a new one",
+                    "type": "integer"
+                },
+                "duration": {
+                    "type": "number"
+                },
+                "file_name": {
+                    "type": "string"
+                },
+                "file_size": {
+                    "type": "integer"
+                },
+                "focal_point_x": {
+                    "type": "number"
+                },
+                "focal_point_y": {
+                    "type": "number"
+                },
+                "frame_rate": {
+                    "type": "number"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "moderated_at": {
+                    "type": "string"
+                },
+                "orientation": {
+                    "type": "integer"
+                },
+                "original_sha256": {
+                    "type": "string"
+                },
+                "output_blurhash": {
+                    "type": "string"
+                },
+                "output_palette": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "output_s3_key": {
+                    "type": "string"
+                },
+                "palette": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "perceptual_hash": {
+                    "type": "string"
+                },
+                "processed_at": {
+                    "type": "string"
+                },
+                "renditions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AssetRendition"
+                    }
+                },
+                "s3_bucket": {
+                    "type": "string"
+                },
+                "s3_key": {
+                    "type": "string"
+                },
+                "sha256": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.AssetStatus"
+                },
+                "thumbnail_urls": {
+                    "description": "ThumbnailURLs maps each thumbnail size to a signed URL when requested by the API",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "thumbnails": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Thumbnail"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "upload_expires_at": {
+                    "type": "string"
+                },
+                "uploaded_at": {
+                    "type": "string"
+                },
+                "verdict": {
+                    "type": "string"
+                },
+                "verdict_labels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "verdict_reason": {
+                    "type": "string"
+                },
+                "video_codec": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AssetRendition": {
+            "type": "object",
+            "properties": {
+                "asset_id": {
+                    "type": "integer"
+                },
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "file_size": {
+                    "type": "integer"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "profile": {
+                    "type": "string"
+                },
+                "s3_key": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AssetStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "uploaded",
+                "processed",
+                "process_failed",
+                "upload_failed",
+                "pending_processing"
+            ],
+            "x-enum-varnames": [
+                "AssetStatusPending",
+                "AssetStatusUploaded",
+                "AssetStatusProcessed",
+                "AssetStatusProcessFailed",
+                "AssetStatusUploadFailed",
+                "AssetStatusPendingProcessing"
+            ]
+        },
+        "models.DispatchStatus": {
+            "type": "string",
+            "enum": [
+                "sent",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "DispatchStatusSent",
+                "DispatchStatusFailed"
+            ]
+        },
+        "models.RetryPolicy": {
+            "type": "object",
+            "properties": {
+                "base_delay": {
+                    "description": "BaseDelay is the delay before the first retry in seconds; it doubles with every attempt",
+                    "type": "number"
+                },
+                "jitter": {
+                    "description": "Jitter randomizes each delay by up to this fraction (0-1)",
+                    "type": "number"
+                },
+                "max_attempts": {
+                    "description": "MaxAttempts is the total number of attempts, including the first one",
+                    "type": "integer"
+                },
+                "max_delay": {
+                    "description": "MaxDelay caps the delay between attempts in seconds",
+                    "type": "number"
+                }
+            }
+        },
+        "models.Task": {
+            "type": "object",
+            "properties": {
+                "asset": {
+                    "$ref": "#/definitions/models.Asset"
+                },
+                "asset_id": {
+                    "type": "integer"
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "cancelled_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "dispatches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TaskDispatch"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "failed_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "next_retry_at": {
+                    "type": "string"
+                },
+                "queued_at": {
+                    "type": "string"
+                },
+                "requeued_at": {
+                    "type": "string"
+                },
+                "run": {
+                    "type": "integer"
+                },
+                "runs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TaskRun"
+                    }
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.TaskStatus"
+                },
+                "succeeded_at": {
+                    "type": "string"
+                },
+                "template": {
+                    "$ref": "#/definitions/models.Template"
+                },
+                "template_id": {
+                    "type": "integer"
+                },
+                "timed_out_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TaskBatch": {
+            "type": "object",
+            "properties": {
+                "cartesian": {
+                    "type": "boolean"
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "existing": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TaskBatchItem"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TaskBatchItem": {
+            "type": "object",
+            "properties": {
+                "asset_id": {
+                    "type": "integer"
+                },
+                "batch_id": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "result": {
+                    "$ref": "#/definitions/models.TaskBatchResult"
+                },
+                "task_id": {
+                    "type": "integer"
+                },
+                "task_status": {
+                    "description": "TaskStatus is the current status of the task, loaded with the batch",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.TaskStatus"
+                        }
+                    ]
+                },
+                "template_id": {
+                    "type": "integer"
+                },
+                "violations": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.TaskBatchResult": {
+            "type": "string",
+            "enum": [
+                "created",
+                "existing",
+                "invalid"
+            ],
+            "x-enum-varnames": [
+                "TaskBatchResultCreated",
+                "TaskBatchResultExisting",
+                "TaskBatchResultInvalid"
+            ]
+        },
+        "models.TaskDispatch": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "output_key": {
+                    "type": "string"
+                },
+                "run": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.DispatchStatus"
+                },
+                "task_id": {
+                    "type": "integer"
+                },
+                "transport": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TaskRun": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "output_s3_key": {
+                    "type": "string"
+                },
+                "queued_at": {
+                    "type": "string"
+                },
+                "run": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.TaskStatus"
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TaskStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "queued",
+                "running",
+                "succeeded",
+                "failed",
+                "cancelled",
+                "retry_scheduled",
+                "timed_out"
+            ],
+            "x-enum-varnames": [
+                "TaskStatusPending",
+                "TaskStatusQueued",
+                "TaskStatusRunning",
+                "TaskStatusSucceeded",
+                "TaskStatusFailed",
+                "TaskStatusCancelled",
+                "TaskStatusRetryScheduled",
+                "TaskStatusTimedOut"
+            ]
+        },
+        "models.Template": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "duration": {
+                    "type": "number"
+                },
+                "frame_rate": {
+                    "type": "number"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "requirements": {
+                    "$ref": "#/definitions/models.TemplateRequirements"
+                },
+                "retry_policy": {
+                    "$ref": "#/definitions/models.RetryPolicy"
+                },
+                "s3_bucket": {
+                    "type": "string"
+                },
+                "s3_key": {
+                    "type": "string"
+                },
+                "task_timeout": {
+                    "description": "TaskTimeout is how long a task may take after it is queued, in seconds; nil uses TASK_TIMEOUT",
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "video_codec": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TemplateRequirements": {
+            "type": "object",
+            "properties": {
+                "accepted_media_types": {
+                    "description": "AcceptedMediaTypes lists content types such as \"video/mp4\" or wildcards such as \"image/*\"",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "aspect_ratios": {
+                    "description": "AspectRatios lists accepted width:height ratios such as \"16:9\"",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "max_duration": {
+                    "type": "number"
+                },
+                "min_duration": {
+                    "description": "MinDuration and MaxDuration bound the duration of video assets in seconds",
+                    "type": "number"
+                }
+            }
+        },
+        "models.Thumbnail": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "size": {
+                    "description": "Size is the configured longest edge the thumbnail was generated for",
+                    "type": "integer"
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.TaskBatchProgress": {
+            "type": "object",
+            "properties": {
+                "cartesian": {
+                    "type": "boolean"
+                },
+                "completed": {
+                    "description": "Completed reports whether every task of the batch has finished",
+                    "type": "boolean"
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "existing": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TaskBatchItem"
+                    }
+                },
+                "statuses": {
+                    "description": "Statuses counts the batch's tasks by status; invalid items have no task",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
         }
     }
 }`
@@ -1,11 +1,16 @@
 package controllers
 
 import (
+	"errors"
+	"mime"
 	"net/http"
 	"strconv"
 
+	"screensaver-ad-backend/config"
 	"screensaver-ad-backend/internal/models"
+	"screensaver-ad-backend/internal/moderation"
 	"screensaver-ad-backend/internal/services"
+	"screensaver-ad-backend/internal/storage"
 
 	"github.com/gin-gonic/gin"
 )
@@ -22,51 +27,165 @@ func NewAssetController(service *services.AssetService) *AssetController {
 
 // CreateAsset handles POST /assets with file upload
 // @Summary Create a new asset
-// @Description Upload a new asset file with metadata
+// @Description Upload a new asset file with metadata. The body is streamed to storage, so the name field must be sent before the file.
 // @Tags assets
 // @Accept multipart/form-data
 // @Produce json
 // @Param file formData file true "Asset file"
 // @Param name formData string false "Asset name (defaults to filename)"
 // @Success 201 {object} map[string]interface{} "Asset created successfully"
+// @Success 200 {object} map[string]interface{} "Identical file already exists, existing asset returned with duplicate_of"
 // @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 413 {object} map[string]interface{} "File too large"
+// @Failure 415 {object} map[string]interface{} "File content is not a supported type or does not match the declared type (code: unsupported_content_type, content_type_mismatch, corrupt_image, corrupt_container)"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /assets [post]
 func (c *AssetController) CreateAsset(ctx *gin.Context) {
-	// Parse multipart form
-	if err := ctx.Request.ParseMultipartForm(32 << 20); err != nil { // 32 MB max
+	// Stream the multipart body instead of buffering it
+	reader, err := ctx.Request.MultipartReader()
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse form data"})
 		return
 	}
 
 	// Get file from form
-	file, fileHeader, err := ctx.Request.FormFile("file")
+	fields, part, err := nextFilePart(reader, "file")
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
 		return
 	}
-	defer file.Close()
+	defer part.Close()
 
 	// Get name from form (optional)
-	name := ctx.Request.FormValue("name")
+	name := fields["name"]
 	if name == "" {
 		// Use original filename if name not provided
-		name = fileHeader.Filename
+		name = part.FileName()
 	}
 
 	// Create asset with file upload
-	asset, err := c.service.CreateAssetWithUpload(file, fileHeader, name)
+	asset, err := c.service.CreateAssetWithUpload(part, part.FileName(), part.Header.Get("Content-Type"), name)
 	if err != nil {
+		if errors.Is(err, services.ErrFileTooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		var contentErr *services.ContentError
+		if errors.As(err, &contentErr) {
+			ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": contentErr.Message, "code": contentErr.Code})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if asset.DuplicateOf != nil {
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":      "Asset already exists",
+			"asset":        asset,
+			"duplicate_of": asset.DuplicateOf,
+		})
+		return
+	}
+
 	ctx.JSON(http.StatusCreated, gin.H{
 		"message": "Asset created successfully",
 		"asset":   asset,
 	})
 }
 
+// InitiateUpload handles POST /assets/uploads
+// @Summary Start a direct upload
+// @Description Create a pending asset and return a presigned PUT URL for uploading the file directly to storage
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param upload body object{file_name=string,content_type=string,file_size=int,name=string} true "Upload request"
+// @Success 201 {object} map[string]interface{} "Pending asset and upload URL"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 413 {object} map[string]interface{} "File too large"
+// @Router /assets/uploads [post]
+func (c *AssetController) InitiateUpload(ctx *gin.Context) {
+	var request struct {
+		FileName    string `json:"file_name" binding:"required"`
+		ContentType string `json:"content_type" binding:"required"`
+		FileSize    int64  `json:"file_size" binding:"required"`
+		Name        string `json:"name,omitempty"`
+	}
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	asset, uploadURL, err := c.service.InitiateUpload(request.Name, request.FileName, request.ContentType, request.FileSize)
+	if err != nil {
+		if errors.Is(err, services.ErrFileTooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"asset":      asset,
+		"upload_url": uploadURL,
+		"method":     http.MethodPut,
+		"headers":    gin.H{"Content-Type": asset.ContentType},
+		"expires_at": asset.UploadExpiresAt,
+	})
+}
+
+// CompleteUpload handles POST /assets/:id/complete
+// @Summary Complete a direct upload
+// @Description Verify that the file of a pending asset reached storage and mark the asset as uploaded
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param id path int true "Asset ID"
+// @Success 200 {object} map[string]interface{} "Upload completed, or existing asset returned with duplicate_of when the content is identical"
+// @Failure 400 {object} map[string]interface{} "Upload could not be verified"
+// @Failure 404 {object} map[string]interface{} "Asset not found"
+// @Failure 415 {object} map[string]interface{} "File content is not a supported type or does not match the declared type, or the image or video container is corrupt"
+// @Router /assets/{id}/complete [post]
+func (c *AssetController) CompleteUpload(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	asset, err := c.service.CompleteUpload(uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrAssetNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Asset not found"})
+			return
+		}
+		var contentErr *services.ContentError
+		if errors.As(err, &contentErr) {
+			ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": contentErr.Message, "code": contentErr.Code})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if asset.DuplicateOf != nil {
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":      "Asset already exists",
+			"asset":        asset,
+			"duplicate_of": asset.DuplicateOf,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Upload completed successfully",
+		"asset":   asset,
+	})
+}
+
 // GetAsset handles GET /assets/:id
 // @Summary Get asset by ID
 // @Description Retrieve a specific asset by its ID
@@ -184,6 +303,72 @@ func (c *AssetController) DeleteAsset(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"message": "Asset deleted successfully"})
 }
 
+// ListTrash handles GET /assets/trash
+// @Summary List deleted assets
+// @Description Get a paginated list of deleted assets that can still be restored before they are purged
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} map[string]interface{} "List of deleted assets"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /assets/trash [get]
+func (c *AssetController) ListTrash(ctx *gin.Context) {
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+
+	assets, count, err := c.service.ListTrash(limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deleted assets"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"assets":           assets,
+		"total":            count,
+		"limit":            limit,
+		"offset":           offset,
+		"retention_period": config.GetRetentionConfig().Period.String(),
+	})
+}
+
+// RestoreAsset handles POST /assets/:id/restore
+// @Summary Restore a deleted asset
+// @Description Undo the deletion of an asset that is still inside the retention period
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param id path int true "Asset ID"
+// @Success 200 {object} models.Asset "Restored asset"
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 404 {object} map[string]interface{} "Deleted asset not found"
+// @Failure 410 {object} map[string]interface{} "Retention period has passed"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /assets/{id}/restore [post]
+func (c *AssetController) RestoreAsset(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	asset, err := c.service.RestoreAsset(uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAssetNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Deleted asset not found"})
+		case errors.Is(err, services.ErrRetentionExpired):
+			ctx.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, asset)
+}
+
 // UpdateAssetStatus handles PATCH /assets/:id/status
 // @Summary Update asset status and the output url
 // @Description Update the status of an asset (uploaded, processed, upload_failed, process_failed) and the output url
@@ -233,14 +418,16 @@ func (c *AssetController) UpdateAssetStatus(ctx *gin.Context) {
 
 // GetAssetURL handles GET /assets/:id/url
 // @Summary Get asset URLs
-// @Description Generate presigned URLs for both input and output asset files
+// @Description Generate signed URLs (storage presigned or CDN, see URL_SIGNER) for both input and output asset files, the thumbnails and optionally the rendition for a screen profile
 // @Tags assets
 // @Accept json
 // @Produce json
 // @Param id path int true "Asset ID"
 // @Param expiration query int false "URL expiration time in minutes" default(60)
-// @Success 200 {object} map[string]interface{} "Presigned URLs for input and output files"
-// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Param profile query string false "Screen profile whose rendition URL is returned, e.g. landscape-1080p"
+// @Success 200 {object} map[string]interface{} "Signed URLs for input and output files"
+// @Failure 400 {object} map[string]interface{} "Invalid ID or unknown profile"
+// @Failure 404 {object} map[string]interface{} "Asset or rendition not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /assets/{id}/url [get]
 func (c *AssetController) GetAssetURL(ctx *gin.Context) {
@@ -253,16 +440,218 @@ func (c *AssetController) GetAssetURL(ctx *gin.Context) {
 	// Get expiration from query parameter (default 60 minutes)
 	expiration, _ := strconv.Atoi(ctx.DefaultQuery("expiration", "60"))
 
-	// Generate presigned URLs for both input and output files
-	urls, err := c.service.GetAssetURLs(uint(id), expiration)
+	// Generate signed URLs for both input and output files
+	urls, err := c.service.GetAssetURLs(uint(id), expiration, ctx.Query("profile"))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, services.ErrUnknownProfile):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrAssetNotFound), errors.Is(err, services.ErrRenditionNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	response := gin.H{
+		"input_url":      urls.InputURL,
+		"output_url":     urls.OutputURL,
+		"thumbnail_urls": urls.ThumbnailURLs,
+		"expires_in":     expiration,
+	}
+	if urls.Rendition != nil {
+		response["rendition"] = urls.Rendition
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// SetFocalPoint handles PUT /assets/:id/focal-point
+// @Summary Set the focal point of an image asset
+// @Description Set the point (x and y from 0 to 1, from the top left) that screen renditions are cropped around and regenerate the renditions. Send nulls to fall back to automatic subject detection.
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param id path int true "Asset ID"
+// @Param focal_point body object{x=number,y=number} true "Focal point"
+// @Success 200 {object} models.Asset "Asset with regenerated renditions"
+// @Failure 400 {object} map[string]interface{} "Invalid focal point or not an image"
+// @Failure 404 {object} map[string]interface{} "Asset not found"
+// @Router /assets/{id}/focal-point [put]
+func (c *AssetController) SetFocalPoint(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	// Null values clear the focal point
+	var request struct {
+		X *float64 `json:"x"`
+		Y *float64 `json:"y"`
+	}
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	asset, err := c.service.SetFocalPoint(uint(id), request.X, request.Y)
+	if err != nil {
+		if errors.Is(err, services.ErrAssetNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Asset not found"})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, asset)
+}
+
+// StreamAsset handles GET /assets/:id/content
+// @Summary Stream an asset file
+// @Description Stream the input or output file of an asset through the server. Supports Range requests (206 Partial Content), ETag and Last-Modified for players that cannot reach storage URLs directly.
+// @Tags assets
+// @Produce octet-stream
+// @Param id path int true "Asset ID"
+// @Param variant query string false "File to stream" Enums(input, output) default(input)
+// @Param download query bool false "Send as attachment instead of inline"
+// @Param Range header string false "Byte range, e.g. bytes=0-1023"
+// @Success 200 {file} file "Full file"
+// @Success 206 {file} file "Requested byte range"
+// @Failure 400 {object} map[string]interface{} "Invalid ID or variant"
+// @Failure 404 {object} map[string]interface{} "Asset or file not found"
+// @Failure 416 "Range not satisfiable"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /assets/{id}/content [get]
+func (c *AssetController) StreamAsset(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	content, err := c.service.OpenAssetContent(ctx.Request.Context(), uint(id), ctx.Query("variant"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidVariant):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrAssetNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Asset not found"})
+		case errors.Is(err, services.ErrOutputNotAvailable):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, storage.ErrNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "File not found in storage"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	defer content.Reader.Close()
+
+	disposition := "inline"
+	if ctx.Query("download") == "true" {
+		disposition = "attachment"
+	}
+	ctx.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": content.FileName}))
+	if content.ContentType != "" {
+		ctx.Header("Content-Type", content.ContentType)
+	}
+	if content.Info.ETag != "" {
+		ctx.Header("ETag", content.Info.ETag)
+	}
+	http.ServeContent(ctx.Writer, ctx.Request, content.FileName, content.Info.LastModified, content.Reader)
+}
+
+// GetSimilarAssets handles GET /assets/:id/similar
+// @Summary Find near-duplicate assets
+// @Description List image assets whose perceptual hash (dHash) is within a Hamming distance of the asset's hash, closest first. Finds re-exported, re-compressed or resized copies that exact-hash deduplication misses.
+// @Tags assets
+// @Produce json
+// @Param id path int true "Asset ID"
+// @Param threshold query int false "Maximum Hamming distance (0-64)" default(10)
+// @Success 200 {object} map[string]interface{} "Similar assets with their distance"
+// @Failure 400 {object} map[string]interface{} "Invalid ID or threshold"
+// @Failure 404 {object} map[string]interface{} "Asset not found"
+// @Failure 422 {object} map[string]interface{} "Asset has no perceptual hash"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /assets/{id}/similar [get]
+func (c *AssetController) GetSimilarAssets(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	threshold, err := strconv.Atoi(ctx.DefaultQuery("threshold", strconv.Itoa(services.DefaultSimilarityThreshold)))
+	if err != nil || threshold < 0 || threshold > 64 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "threshold must be between 0 and 64"})
+		return
+	}
+
+	similar, err := c.service.FindSimilarAssets(uint(id), threshold)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAssetNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Asset not found"})
+		case errors.Is(err, services.ErrNoPerceptualHash):
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"input_url":  urls.InputURL,
-		"output_url": urls.OutputURL,
-		"expires_in": expiration,
+		"asset_id":  id,
+		"threshold": threshold,
+		"similar":   similar,
 	})
 }
+
+// ReviewAsset handles PUT /assets/:id/review
+// @Summary Review the moderation verdict of an asset
+// @Description Override the moderation verdict after a manual review, e.g. to approve or reject assets marked needs_review. Rejected assets cannot be used in tasks.
+// @Tags assets
+// @Accept json
+// @Produce json
+// @Param id path int true "Asset ID"
+// @Param review body object{verdict=string,reason=string} true "Verdict (approve, reject or needs_review) and reason"
+// @Success 200 {object} models.Asset "Reviewed asset"
+// @Failure 400 {object} map[string]interface{} "Invalid ID or verdict"
+// @Failure 404 {object} map[string]interface{} "Asset not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /assets/{id}/review [put]
+func (c *AssetController) ReviewAsset(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var request struct {
+		Verdict moderation.Decision `json:"verdict" binding:"required"`
+		Reason  string              `json:"reason"`
+	}
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	asset, err := c.service.ReviewAsset(uint(id), request.Verdict, request.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidVerdict):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrAssetNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Asset not found"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, asset)
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+)
+
+// maxFormFieldSize bounds the size of non-file form fields read from a streamed body
+const maxFormFieldSize = 4 << 10
+
+// nextFilePart streams a multipart body, collecting plain form fields until the
+// file part named fileField is reached. The file part is returned unread so it can
+// be streamed to storage; fields sent after the file are not available.
+func nextFilePart(reader *multipart.Reader, fileField string) (map[string]string, *multipart.Part, error) {
+	fields := map[string]string{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return fields, nil, fmt.Errorf("file is required")
+		}
+		if err != nil {
+			return fields, nil, err
+		}
+
+		if part.FormName() == fileField && part.FileName() != "" {
+			return fields, part, nil
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, maxFormFieldSize))
+		part.Close()
+		if err != nil {
+			return fields, nil, err
+		}
+		fields[part.FormName()] = string(value)
+	}
+}
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"screensaver-ad-backend/config"
+	"screensaver-ad-backend/internal/media"
+	"screensaver-ad-backend/internal/services"
+	"screensaver-ad-backend/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StorageController serves objects of the local storage backend through signed URLs
+type StorageController struct {
+	store *storage.LocalStorage
+}
+
+// NewStorageController creates a new storage controller instance
+func NewStorageController(store *storage.LocalStorage) *StorageController {
+	return &StorageController{store: store}
+}
+
+// GetObject handles GET /storage/*key for URLs signed by the local backend
+func (c *StorageController) GetObject(ctx *gin.Context) {
+	key := strings.TrimPrefix(ctx.Param("key"), "/")
+	if err := c.store.VerifySignature(http.MethodGet, key, ctx.Query("expires"), ctx.Query("signature")); err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	body, info, err := c.store.Get(ctx.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Object not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read object"})
+		return
+	}
+	defer body.Close()
+
+	ctx.Header("Content-Type", info.ContentType)
+	ctx.Header("ETag", info.ETag)
+	if seeker, ok := body.(io.ReadSeeker); ok {
+		http.ServeContent(ctx.Writer, ctx.Request, "", info.LastModified, seeker)
+		return
+	}
+	ctx.DataFromReader(http.StatusOK, info.Size, info.ContentType, body, nil)
+}
+
+// PutObject handles PUT /storage/*key for upload URLs signed by the local backend
+func (c *StorageController) PutObject(ctx *gin.Context) {
+	key := strings.TrimPrefix(ctx.Param("key"), "/")
+	if err := c.store.VerifySignature(http.MethodPut, key, ctx.Query("expires"), ctx.Query("signature")); err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := ctx.GetHeader("Content-Type")
+	limit := config.GetUploadConfig().MaxSizeFor(media.NormalizeContentType(contentType))
+	if ctx.Request.ContentLength > limit {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": services.ErrFileTooLarge.Error()})
+		return
+	}
+
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, limit)
+	if err := c.store.Put(ctx.Request.Context(), key, body, ctx.Request.ContentLength, contentType); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": services.ErrFileTooLarge.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store object"})
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
@@ -1,9 +1,15 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"screensaver-ad-backend/internal/models"
+	"screensaver-ad-backend/internal/repository"
 	"screensaver-ad-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -20,14 +26,16 @@ func NewTaskController(service *services.TaskService) *TaskController {
 
 // CreateTask handles POST /tasks
 // @Summary Create a new task
-// @Description Create a new task if no record exists with same asset and template IDs
+// @Description Create a new task if no record exists with same asset and template IDs. The asset must be uploaded or processed and meet the template requirements (media types, aspect ratios, duration bounds); otherwise every violated rule is listed.
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param task body object{template_id=uint,asset_id=uint,metadata=object} true "Task object"
 // @Success 201 {object} map[string]interface{} "Task created successfully"
-// @Success 202 {object} map[string]interface{} "Task already exists"
+// @Success 202 {object} map[string]interface{} "Task already exists (task_id names it for a re-run)"
 // @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Asset or template not found"
+// @Failure 422 {object} map[string]interface{} "Asset is not compatible with the template (violations: [{rule, message}])"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /tasks [post]
 func (c *TaskController) CreateTask(ctx *gin.Context) {
@@ -50,13 +58,421 @@ func (c *TaskController) CreateTask(ctx *gin.Context) {
 
 	created, err := c.service.CreateTaskIfNotExists(task)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondTaskError(ctx, err)
 		return
 	}
 
 	if created {
-		ctx.JSON(http.StatusCreated, gin.H{"message": "Task created successfully"})
+		ctx.JSON(http.StatusCreated, gin.H{"message": "Task created successfully", "task_id": task.ID})
 	} else {
-		ctx.JSON(http.StatusAccepted, gin.H{"message": "Task already exists"})
+		ctx.JSON(http.StatusAccepted, gin.H{"message": "Task already exists; use POST /api/tasks/{id}/rerun to process it again", "task_id": task.ID})
+	}
+}
+
+// CreateTaskBatch handles POST /tasks/batch
+// @Summary Create tasks in a batch
+// @Description Create the tasks for many asset and template pairs in one transaction. Without cartesian, asset_ids and template_ids are paired by position; with cartesian, every asset is paired with every template (at most 1000 pairs). Each pair is reported as created, existing or invalid (unknown asset or template, or not compatible, with the violations). New tasks are dispatched to the worker. Use the returned batch ID to follow its completion.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param batch body object{asset_ids=[]uint,template_ids=[]uint,cartesian=bool,metadata=object} true "Batch request"
+// @Success 201 {object} models.TaskBatch "Batch with per-item results"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /tasks/batch [post]
+func (c *TaskController) CreateTaskBatch(ctx *gin.Context) {
+	var request struct {
+		AssetIDs    []uint                 `json:"asset_ids" binding:"required"`
+		TemplateIDs []uint                 `json:"template_ids" binding:"required"`
+		Cartesian   bool                   `json:"cartesian"`
+		Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	}
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	batch, err := c.service.CreateTaskBatch(services.TaskBatchRequest{
+		AssetIDs:    request.AssetIDs,
+		TemplateIDs: request.TemplateIDs,
+		Cartesian:   request.Cartesian,
+		Metadata:    request.Metadata,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidBatch) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, batch)
+}
+
+// GetTaskBatch handles GET /tasks/batch/:id
+// @Summary Get a task batch
+// @Description Get a batch with its items, the current status of each item's task, the number of tasks per status and whether every task has finished (succeeded, failed or cancelled)
+// @Tags tasks
+// @Produce json
+// @Param id path int true "Batch ID"
+// @Success 200 {object} services.TaskBatchProgress
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 404 {object} map[string]interface{} "Batch not found"
+// @Router /tasks/batch/{id} [get]
+func (c *TaskController) GetTaskBatch(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	progress, err := c.service.GetTaskBatch(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Batch not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, progress)
+}
+
+// ListTasks handles GET /tasks
+// @Summary List tasks
+// @Description Get a paginated list of tasks with their asset and template, newest first
+// @Tags tasks
+// @Produce json
+// @Param status query string false "Comma separated statuses (pending, queued, running, succeeded, failed, cancelled, retry_scheduled, timed_out)"
+// @Param asset_id query int false "Asset ID"
+// @Param template_id query int false "Template ID"
+// @Param created_from query string false "Created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param created_to query string false "Created before (RFC 3339 or YYYY-MM-DD)"
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} map[string]interface{} "List of tasks with pagination info"
+// @Failure 400 {object} map[string]interface{} "Invalid filter"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /tasks [get]
+func (c *TaskController) ListTasks(ctx *gin.Context) {
+	filter, err := parseTaskFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.AssetID, err = parseOptionalID(ctx.Query("asset_id")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid asset_id"})
+		return
+	}
+	if filter.TemplateID, err = parseOptionalID(ctx.Query("template_id")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template_id"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+
+	tasks, count, err := c.service.ListTasks(filter, limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
+		return
+	}
+	respondTasks(ctx, tasks, count, limit, offset)
+}
+
+// GetTask handles GET /tasks/:id
+// @Summary Get a task
+// @Description Get a task with its asset and template
+// @Tags tasks
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} models.Task
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 404 {object} map[string]interface{} "Task not found"
+// @Router /tasks/{id} [get]
+func (c *TaskController) GetTask(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	task, err := c.service.GetTask(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, task)
+}
+
+// ListAssetTasks handles GET /assets/:id/tasks
+// @Summary List the tasks of an asset
+// @Description Get a paginated list of the tasks that use an asset, newest first
+// @Tags tasks
+// @Produce json
+// @Param id path int true "Asset ID"
+// @Param status query string false "Comma separated statuses"
+// @Param created_from query string false "Created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param created_to query string false "Created before (RFC 3339 or YYYY-MM-DD)"
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} map[string]interface{} "List of tasks with pagination info"
+// @Failure 400 {object} map[string]interface{} "Invalid ID or filter"
+// @Failure 404 {object} map[string]interface{} "Asset not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /assets/{id}/tasks [get]
+func (c *TaskController) ListAssetTasks(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	filter, err := parseTaskFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+
+	tasks, count, err := c.service.ListAssetTasks(uint(id), filter, limit, offset)
+	if err != nil {
+		if errors.Is(err, services.ErrAssetNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Asset not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
+		return
+	}
+	respondTasks(ctx, tasks, count, limit, offset)
+}
+
+// ListTemplateTasks handles GET /templates/:id/tasks
+// @Summary List the tasks of a template
+// @Description Get a paginated list of the tasks that use a template, newest first
+// @Tags tasks
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param status query string false "Comma separated statuses"
+// @Param created_from query string false "Created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param created_to query string false "Created before (RFC 3339 or YYYY-MM-DD)"
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} map[string]interface{} "List of tasks with pagination info"
+// @Failure 400 {object} map[string]interface{} "Invalid ID or filter"
+// @Failure 404 {object} map[string]interface{} "Template not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /templates/{id}/tasks [get]
+func (c *TaskController) ListTemplateTasks(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	filter, err := parseTaskFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+
+	tasks, count, err := c.service.ListTemplateTasks(uint(id), filter, limit, offset)
+	if err != nil {
+		if errors.Is(err, services.ErrTemplateNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
+		return
+	}
+	respondTasks(ctx, tasks, count, limit, offset)
+}
+
+func respondTasks(ctx *gin.Context, tasks []models.Task, count int64, limit, offset int) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"tasks":  tasks,
+		"total":  count,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// parseTaskFilter reads the status and creation date filters shared by the task listings
+func parseTaskFilter(ctx *gin.Context) (repository.TaskFilter, error) {
+	var filter repository.TaskFilter
+	if value := ctx.Query("status"); value != "" {
+		for _, part := range strings.Split(value, ",") {
+			status := models.TaskStatus(strings.TrimSpace(part))
+			if !status.Valid() {
+				return filter, fmt.Errorf("invalid status %q", part)
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseTimeQuery(ctx.Query("created_from")); err != nil {
+		return filter, fmt.Errorf("invalid created_from: %w", err)
+	}
+	if filter.CreatedTo, err = parseTimeQuery(ctx.Query("created_to")); err != nil {
+		return filter, fmt.Errorf("invalid created_to: %w", err)
+	}
+	return filter, nil
+}
+
+// parseTimeQuery parses an RFC 3339 timestamp or a YYYY-MM-DD date (midnight UTC).
+// An empty value yields nil.
+func parseTimeQuery(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not an RFC 3339 time or YYYY-MM-DD date", value)
+	}
+	return &t, nil
+}
+
+// parseOptionalID parses an optional numeric ID query value; empty yields 0
+func parseOptionalID(value string) (uint, error) {
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	return uint(id), err
+}
+
+// CancelTask handles POST /tasks/:id/cancel
+// @Summary Cancel a task
+// @Description Cancel a task that has not finished (pending, queued, running, retry_scheduled or timed_out). Later worker events for the task, including processed, are ignored.
+// @Tags tasks
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} models.Task "Cancelled task"
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 404 {object} map[string]interface{} "Task not found"
+// @Failure 409 {object} map[string]interface{} "Task has already finished"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /tasks/{id}/cancel [post]
+func (c *TaskController) CancelTask(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	task, err := c.service.CancelTask(uint(id))
+	if err != nil {
+		respondTaskError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, task)
+}
+
+// RerunTask handles POST /tasks/:id/rerun
+// @Summary Re-run a task
+// @Description Start a new run of a finished task (succeeded, failed or cancelled), e.g. after fixing its template. The finished run is kept in the task's runs history with its output key and metadata, and the new run renders to a new output key.
+// @Tags tasks
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} models.Task "Task with the new run"
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 404 {object} map[string]interface{} "Task, asset or template not found"
+// @Failure 409 {object} map[string]interface{} "Task has not finished"
+// @Failure 422 {object} map[string]interface{} "Asset is no longer compatible with the template"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /tasks/{id}/rerun [post]
+func (c *TaskController) RerunTask(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	task, err := c.service.RerunTask(uint(id))
+	if err != nil {
+		respondTaskError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, task)
+}
+
+// ListDeadLetterTasks handles GET /tasks/dead-letter
+// @Summary List timed-out tasks
+// @Description Get a paginated list of tasks the worker did not finish within their template's timeout, newest first. Requeue them with POST /tasks/{id}/requeue.
+// @Tags tasks
+// @Produce json
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} map[string]interface{} "List of tasks with pagination info"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /tasks/dead-letter [get]
+func (c *TaskController) ListDeadLetterTasks(ctx *gin.Context) {
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+
+	tasks, count, err := c.service.ListDeadLetterTasks(limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
+		return
+	}
+	respondTasks(ctx, tasks, count, limit, offset)
+}
+
+// RequeueTask handles POST /tasks/:id/requeue
+// @Summary Requeue a timed-out task
+// @Description Move a timed_out task back to pending with a fresh retry budget and dispatch it to the worker again
+// @Tags tasks
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} models.Task "Requeued task"
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 404 {object} map[string]interface{} "Task, asset or template not found"
+// @Failure 409 {object} map[string]interface{} "Task is not timed out"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /tasks/{id}/requeue [post]
+func (c *TaskController) RequeueTask(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	task, err := c.service.RequeueTask(uint(id))
+	if err != nil {
+		respondTaskError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, task)
+}
+
+// respondTaskError maps task service errors to responses
+func respondTaskError(ctx *gin.Context, err error) {
+	var compatErr *services.CompatibilityError
+	switch {
+	case errors.Is(err, services.ErrTaskNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+	case errors.Is(err, services.ErrAssetNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Asset not found"})
+	case errors.Is(err, services.ErrTemplateNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+	case errors.Is(err, services.ErrInvalidTransition):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.As(err, &compatErr):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":      "asset is not compatible with template",
+			"violations": compatErr.Violations,
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
 }
@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"screensaver-ad-backend/internal/models"
@@ -11,17 +13,17 @@ import (
 )
 
 type TemplateController struct {
-	service   *services.TemplateService
-	s3Service *services.S3Service
+	service        *services.TemplateService
+	storageService *services.StorageService
 }
 
-func NewTemplateController(service *services.TemplateService, s3Service *services.S3Service) *TemplateController {
-	return &TemplateController{service: service, s3Service: s3Service}
+func NewTemplateController(service *services.TemplateService, storageService *services.StorageService) *TemplateController {
+	return &TemplateController{service: service, storageService: storageService}
 }
 
 // UploadTemplate handles uploading a template video and name
 // @Summary Upload a new template
-// @Description Upload a template video file with a name
+// @Description Upload a template video file with a name. The body is streamed to storage, so the name field must be sent before the file.
 // @Tags templates
 // @Accept multipart/form-data
 // @Produce json
@@ -29,45 +31,45 @@ func NewTemplateController(service *services.TemplateService, s3Service *service
 // @Param file formData file true "Template video file"
 // @Success 200 {object} map[string]interface{} "Template uploaded successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 413 {object} map[string]interface{} "File too large"
+// @Failure 415 {object} map[string]interface{} "File content is not a supported video or does not match the declared type (code: unsupported_content_type, content_type_mismatch, corrupt_container)"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /templates [post]
 func (tc *TemplateController) UploadTemplate(c *gin.Context) {
-	name := c.PostForm("name")
-	file, err := c.FormFile("file")
-	if err != nil || name == "" {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "name and file are required"})
 		return
 	}
 
-	fileObj, err := file.Open()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open file"})
+	fields, part, err := nextFilePart(reader, "file")
+	if err != nil || fields["name"] == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and file are required"})
 		return
 	}
-	defer fileObj.Close()
+	defer part.Close()
 
-	s3Key, err := tc.s3Service.UploadFileToS3(fileObj, file, name, "template")
+	template, err := tc.service.CreateTemplateWithUpload(part, part.FileName(), part.Header.Get("Content-Type"), fields["name"])
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to upload to S3"})
-		return
-	}
-
-	template := &models.Template{
-		Name:     name,
-		S3Key:    s3Key,
-		S3Bucket: tc.s3Service.Bucket,
-	}
-	if err := tc.service.CreateTemplate(template); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save template"})
+		if errors.Is(err, services.ErrFileTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		var contentErr *services.ContentError
+		if errors.As(err, &contentErr) {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": contentErr.Message, "code": contentErr.Code})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "template uploaded", "template": template})
 }
 
-// ListTemplates returns all templates with presigned URLs
+// ListTemplates returns all templates with signed URLs
 // @Summary List all templates
-// @Description Get a list of all templates with presigned URLs
+// @Description Get a list of all templates with signed URLs
 // @Tags templates
 // @Accept json
 // @Produce json
@@ -83,15 +85,144 @@ func (tc *TemplateController) ListTemplates(c *gin.Context) {
 
 	result := []gin.H{}
 	for _, t := range templates {
-		url, err := tc.s3Service.GetFileURL(t.S3Key, 15*time.Minute)
+		url, err := tc.storageService.GetFileURL(t.S3Key, 15*time.Minute)
 		if err != nil {
 			url = ""
 		}
 		result = append(result, gin.H{
-			"id":   t.ID,
-			"name": t.Name,
-			"url":  url,
+			"id":           t.ID,
+			"name":         t.Name,
+			"url":          url,
+			"requirements": t.Requirements,
 		})
 	}
 	c.JSON(http.StatusOK, result)
 }
+
+// UpdateRequirements handles PUT /templates/:id/requirements
+// @Summary Set template requirements
+// @Description Replace the requirements an asset must meet before a task can combine it with the template. Media types are exact content types or wildcards such as image/*, aspect ratios are width:height with 1% tolerance and duration bounds (seconds) apply to video assets. Omitted fields accept everything.
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param requirements body object{accepted_media_types=[]string,aspect_ratios=[]string,min_duration=number,max_duration=number} true "Template requirements"
+// @Success 200 {object} map[string]interface{} "Requirements updated"
+// @Failure 400 {object} map[string]interface{} "Invalid ID or requirements"
+// @Failure 404 {object} map[string]interface{} "Template not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /templates/{id}/requirements [put]
+func (tc *TemplateController) UpdateRequirements(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var request models.TemplateRequirements
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := tc.service.UpdateRequirements(uint(id), request)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidRequirements):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrTemplateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "requirements updated", "template": template})
+}
+
+// UpdateRetryPolicy handles PUT /templates/:id/retry-policy
+// @Summary Set the retry policy of a template
+// @Description Replace how failed tasks of the template are retried: total attempts, delay before the first retry (doubling per attempt), maximum delay (seconds) and jitter (fraction 0-1). Omitted fields use the TASK_RETRY_* defaults.
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param policy body object{max_attempts=int,base_delay=number,max_delay=number,jitter=number} true "Retry policy"
+// @Success 200 {object} map[string]interface{} "Retry policy updated"
+// @Failure 400 {object} map[string]interface{} "Invalid ID or policy"
+// @Failure 404 {object} map[string]interface{} "Template not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /templates/{id}/retry-policy [put]
+func (tc *TemplateController) UpdateRetryPolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var request models.RetryPolicy
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := tc.service.UpdateRetryPolicy(uint(id), request)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidRetryPolicy):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrTemplateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "retry policy updated", "template": template})
+}
+
+// UpdateTaskTimeout handles PUT /templates/:id/timeout
+// @Summary Set the task timeout of a template
+// @Description Set how long tasks of the template may take after they are queued, in seconds, before the sweeper marks them timed_out. A null task_timeout restores the TASK_TIMEOUT default.
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param timeout body object{task_timeout=number} true "Task timeout"
+// @Success 200 {object} map[string]interface{} "Task timeout updated"
+// @Failure 400 {object} map[string]interface{} "Invalid ID or timeout"
+// @Failure 404 {object} map[string]interface{} "Template not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /templates/{id}/timeout [put]
+func (tc *TemplateController) UpdateTaskTimeout(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var request struct {
+		TaskTimeout *float64 `json:"task_timeout"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := tc.service.UpdateTaskTimeout(uint(id), request.TaskTimeout)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidTaskTimeout):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrTemplateNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "task timeout updated", "template": template})
+}
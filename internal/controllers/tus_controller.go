@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"screensaver-ad-backend/config"
+	"screensaver-ad-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,termination,expiration"
+)
+
+// TusController implements the tus 1.0 resumable upload protocol for assets
+type TusController struct {
+	service *services.ResumableUploadService
+}
+
+// NewTusController creates a new tus controller instance
+func NewTusController(service *services.ResumableUploadService) *TusController {
+	return &TusController{service: service}
+}
+
+// Middleware sets the protocol headers and rejects clients speaking another tus version
+func (c *TusController) Middleware(ctx *gin.Context) {
+	ctx.Header("Tus-Resumable", tusVersion)
+	if ctx.Request.Method != http.MethodOptions && ctx.GetHeader("Tus-Resumable") != tusVersion {
+		ctx.Header("Tus-Version", tusVersion)
+		ctx.AbortWithStatus(http.StatusPreconditionFailed)
+		return
+	}
+	ctx.Next()
+}
+
+// Options handles OPTIONS /uploads/tus
+// @Summary Discover tus capabilities
+// @Description Return the supported tus version, extensions and maximum upload size
+// @Tags uploads
+// @Success 204 "Capabilities returned in headers"
+// @Router /uploads/tus [options]
+func (c *TusController) Options(ctx *gin.Context) {
+	uploadConfig := config.GetUploadConfig()
+	maxSize := uploadConfig.MaxVideoSize
+	if uploadConfig.MaxImageSize > maxSize {
+		maxSize = uploadConfig.MaxImageSize
+	}
+
+	ctx.Header("Tus-Version", tusVersion)
+	ctx.Header("Tus-Extension", tusExtensions)
+	ctx.Header("Tus-Max-Size", strconv.FormatInt(maxSize, 10))
+	ctx.Status(http.StatusNoContent)
+}
+
+// CreateUpload handles POST /uploads/tus
+// @Summary Create a resumable upload
+// @Description Start a tus upload. Upload-Metadata must contain filename and filetype and may contain name.
+// @Tags uploads
+// @Param Tus-Resumable header string true "tus protocol version" default(1.0.0)
+// @Param Upload-Length header int true "Total size of the file in bytes"
+// @Param Upload-Metadata header string true "Comma separated base64 encoded metadata (filename, filetype, name)"
+// @Success 201 "Upload created, see Location header"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 413 {object} map[string]interface{} "File too large"
+// @Router /uploads/tus [post]
+func (c *TusController) CreateUpload(ctx *gin.Context) {
+	length, err := strconv.ParseInt(ctx.GetHeader("Upload-Length"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Length header is required"})
+		return
+	}
+
+	metadata, err := parseUploadMetadata(ctx.GetHeader("Upload-Metadata"))
+	if err != nil || metadata["filename"] == "" || metadata["filetype"] == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Metadata must include filename and filetype"})
+		return
+	}
+
+	session, err := c.service.CreateUpload(length, metadata["filename"], metadata["filetype"], metadata["name"])
+	if err != nil {
+		if errors.Is(err, services.ErrFileTooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Location", strings.TrimRight(ctx.Request.URL.Path, "/")+"/"+session.ID)
+	ctx.Header("Upload-Expires", session.ExpiresAt.UTC().Format(http.TimeFormat))
+	ctx.Status(http.StatusCreated)
+}
+
+// GetOffset handles HEAD /uploads/tus/:id
+// @Summary Get the offset of a resumable upload
+// @Description Return how many bytes of the upload have been received
+// @Tags uploads
+// @Param id path string true "Upload ID"
+// @Param Tus-Resumable header string true "tus protocol version" default(1.0.0)
+// @Success 200 "Offset returned in the Upload-Offset header"
+// @Failure 404 "Upload not found"
+// @Router /uploads/tus/{id} [head]
+func (c *TusController) GetOffset(ctx *gin.Context) {
+	session, err := c.service.GetUpload(ctx.Param("id"))
+	if err != nil {
+		c.writeError(ctx, err)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	ctx.Header("Upload-Length", strconv.FormatInt(session.Length, 10))
+	if session.AssetID != nil {
+		ctx.Header("Upload-Asset-Id", strconv.FormatUint(uint64(*session.AssetID), 10))
+	} else {
+		ctx.Header("Upload-Expires", session.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+	ctx.Status(http.StatusOK)
+}
+
+// AppendChunk handles PATCH /uploads/tus/:id
+// @Summary Upload a chunk
+// @Description Append bytes at Upload-Offset. The asset is created once the last byte is received and its ID is returned in the Upload-Asset-Id header.
+// @Tags uploads
+// @Accept application/offset+octet-stream
+// @Param id path string true "Upload ID"
+// @Param Tus-Resumable header string true "tus protocol version" default(1.0.0)
+// @Param Upload-Offset header int true "Offset the chunk starts at"
+// @Success 204 "Chunk stored, new offset in the Upload-Offset header"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 "Upload not found"
+// @Failure 409 {object} map[string]interface{} "Offset mismatch"
+// @Failure 410 {object} map[string]interface{} "Upload failed after the last chunk"
+// @Failure 415 {object} map[string]interface{} "Unsupported content type"
+// @Router /uploads/tus/{id} [patch]
+func (c *TusController) AppendChunk(ctx *gin.Context) {
+	if ctx.GetHeader("Content-Type") != "application/offset+octet-stream" {
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be application/offset+octet-stream"})
+		return
+	}
+
+	offset, err := strconv.ParseInt(ctx.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Offset header is required"})
+		return
+	}
+
+	session, err := c.service.AppendChunk(ctx.Param("id"), offset, ctx.Request.Body)
+	if err != nil {
+		c.writeError(ctx, err)
+		return
+	}
+
+	ctx.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	if session.AssetID != nil {
+		ctx.Header("Upload-Asset-Id", strconv.FormatUint(uint64(*session.AssetID), 10))
+	} else {
+		ctx.Header("Upload-Expires", session.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// TerminateUpload handles DELETE /uploads/tus/:id
+// @Summary Terminate a resumable upload
+// @Description Discard an upload and the chunks received so far
+// @Tags uploads
+// @Param id path string true "Upload ID"
+// @Param Tus-Resumable header string true "tus protocol version" default(1.0.0)
+// @Success 204 "Upload terminated"
+// @Failure 404 "Upload not found"
+// @Router /uploads/tus/{id} [delete]
+func (c *TusController) TerminateUpload(ctx *gin.Context) {
+	if err := c.service.TerminateUpload(ctx.Param("id")); err != nil {
+		c.writeError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *TusController) writeError(ctx *gin.Context, err error) {
+	var contentErr *services.ContentError
+	switch {
+	case errors.As(err, &contentErr):
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": contentErr.Message, "code": contentErr.Code})
+	case errors.Is(err, services.ErrUploadNotFound):
+		ctx.Status(http.StatusNotFound)
+	case errors.Is(err, services.ErrUploadFailed):
+		ctx.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrOffsetMismatch):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrChunkTooLarge), errors.Is(err, services.ErrFileTooLarge):
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// parseUploadMetadata decodes the tus Upload-Metadata header ("key base64value,key2 base64value")
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+	if header == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), " ", 2)
+		if parts[0] == "" {
+			continue
+		}
+		if len(parts) == 1 {
+			metadata[parts[0]] = ""
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		metadata[parts[0]] = string(value)
+	}
+	return metadata, nil
+}
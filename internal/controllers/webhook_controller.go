@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 
 	"screensaver-ad-backend/internal/services"
@@ -22,13 +24,15 @@ func NewWebhookController(taskService *services.TaskService, assetService *servi
 
 // HandleWebhook handles POST /webhook
 // @Summary Handle webhook events
-// @Description Process webhook events with flexible payload structure
+// @Description Process worker events for a task (payload.task_id). Events drive the task status: queued, started (running), processed (succeeded; payload.s3_key sets the asset output) and failed (payload.error is recorded; the task is retried per the template retry policy). Skipped events are filled in, repeated events are ignored and other event types are accepted without effect.
 // @Tags webhook
 // @Accept json
 // @Produce json
 // @Param event body object{event_type=string,payload=object} true "Webhook event with payload"
 // @Success 200 {object} map[string]interface{} "Event processed successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "Task not found"
+// @Failure 409 {object} map[string]interface{} "Event not allowed in the current task status"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /webhook [post]
 func (c *WebhookController) HandleWebhook(ctx *gin.Context) {
@@ -42,12 +46,29 @@ func (c *WebhookController) HandleWebhook(ctx *gin.Context) {
 		return
 	}
 
-	if request.EventType == "processed" {
-		err := c.taskService.UpdateTaskMetadata(request.Payload)
+	switch request.EventType {
+	case services.TaskEventQueued, services.TaskEventStarted, services.TaskEventProcessed, services.TaskEventFailed:
+		task, err := c.taskService.HandleWorkerEvent(request.EventType, request.Payload)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			switch {
+			case errors.Is(err, services.ErrInvalidTaskEvent):
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, services.ErrTaskNotFound):
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			case errors.Is(err, services.ErrInvalidTransition):
+				ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
 			return
 		}
+		if request.EventType == services.TaskEventProcessed {
+			if err := c.assetService.ProcessOutput(task.AssetID); err != nil {
+				log.Printf("Failed to process output of asset %d: %v", task.AssetID, err)
+			}
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": "Event processed successfully", "status": task.Status})
+		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Event processed successfully"})
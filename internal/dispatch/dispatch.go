@@ -0,0 +1,32 @@
+package dispatch
+
+import (
+	"context"
+	"time"
+)
+
+// Job is the message sent to the rendering worker for a task
+type Job struct {
+	TaskID uint `json:"task_id"`
+	// Run numbers the runs of a task; workers echo it in their webhook events
+	Run        int  `json:"run"`
+	AssetID    uint `json:"asset_id"`
+	TemplateID uint `json:"template_id"`
+	// InputURL and TemplateURL are signed download URLs valid until ExpiresAt
+	InputURL         string    `json:"input_url"`
+	InputContentType string    `json:"input_content_type"`
+	TemplateURL      string    `json:"template_url"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	// OutputKey is the storage key the worker writes the rendered output to
+	OutputKey string `json:"output_key"`
+	// CallbackURL receives the worker's webhook events for the task
+	CallbackURL string                 `json:"callback_url,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Transport delivers jobs to the rendering worker
+type Transport interface {
+	// Name identifies the transport in dispatch records
+	Name() string
+	Send(ctx context.Context, job Job) error
+}
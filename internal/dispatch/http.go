@@ -0,0 +1,63 @@
+package dispatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxErrorBodySize bounds the worker response included in send errors
+const maxErrorBodySize = 1024
+
+// HTTPTransport posts jobs as JSON to the worker. Any 2xx response counts as accepted.
+type HTTPTransport struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewHTTPTransport creates a transport posting to url. apiKey is sent as a bearer token when set.
+func NewHTTPTransport(url, apiKey string, timeout time.Duration) *HTTPTransport {
+	return &HTTPTransport{
+		url:    url,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name identifies the transport in dispatch records
+func (t *HTTPTransport) Name() string {
+	return "http"
+}
+
+// Send posts the job to the worker
+func (t *HTTPTransport) Send(ctx context.Context, job Job) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("worker request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return fmt.Errorf("worker returned %s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package dispatch
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryTransport keeps jobs in memory instead of sending them. It is meant for tests
+// and cannot be selected with DISPATCH_TRANSPORT.
+type MemoryTransport struct {
+	mu   sync.Mutex
+	jobs []Job
+	err  error
+}
+
+// NewMemoryTransport creates an empty in-memory transport
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{}
+}
+
+// Name identifies the transport in dispatch records
+func (t *MemoryTransport) Name() string {
+	return "memory"
+}
+
+// Send records the job, or returns the error set with FailWith
+func (t *MemoryTransport) Send(ctx context.Context, job Job) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return t.err
+	}
+	t.jobs = append(t.jobs, job)
+	return nil
+}
+
+// Jobs returns the jobs sent so far
+func (t *MemoryTransport) Jobs() []Job {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Job(nil), t.jobs...)
+}
+
+// FailWith makes subsequent sends fail with err; nil restores delivery
+func (t *MemoryTransport) FailWith(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.err = err
+}
@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"log"
+	"time"
+)
+
+// Every runs fn in a background goroutine once per interval.
+// Errors are logged and do not stop the schedule. A non-positive interval disables the job.
+func Every(name string, interval time.Duration, fn func() error) {
+	if interval <= 0 {
+		log.Printf("Job %s disabled", name)
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := fn(); err != nil {
+				log.Printf("Job %s failed: %v", name, err)
+			}
+		}
+	}()
+	log.Printf("Job %s scheduled every %s", name, interval)
+}
@@ -0,0 +1,104 @@
+package media
+
+import (
+	"image"
+	"math"
+	"strings"
+)
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// blurHashSampleSize is the longest edge images are reduced to before encoding; the
+// few components of a BlurHash carry no detail a larger sample would add
+const blurHashSampleSize = 32
+
+// BlurHash encodes an image as a BlurHash (https://blurha.sh) with xComponents by
+// yComponents DCT components (1-9 each). Clients decode it into a blurred placeholder.
+func BlurHash(img *image.RGBA, xComponents, yComponents int) string {
+	w, h := FitWithin(img.Bounds().Dx(), img.Bounds().Dy(), blurHashSampleSize)
+	small := Resize(img, w, h)
+
+	linear := make([][3]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := small.Pix[y*small.Stride+x*4:]
+			linear[y*w+x] = [3]float64{srgbToLinear(p[0]), srgbToLinear(p[1]), srgbToLinear(p[2])}
+		}
+	}
+
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+			var factor [3]float64
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					basis := math.Cos(math.Pi*float64(i*x)/float64(w)) * math.Cos(math.Pi*float64(j*y)/float64(h))
+					for c := 0; c < 3; c++ {
+						factor[c] += basis * linear[y*w+x][c]
+					}
+				}
+			}
+			scale := normalisation / float64(w*h)
+			factors = append(factors, [3]float64{factor[0] * scale, factor[1] * scale, factor[2] * scale})
+		}
+	}
+
+	var sb strings.Builder
+	encodeBase83(&sb, (xComponents-1)+(yComponents-1)*9, 1)
+
+	dc, ac := factors[0], factors[1:]
+	maximumValue := 1.0
+	if len(ac) > 0 {
+		actualMax := 0.0
+		for _, f := range ac {
+			actualMax = math.Max(actualMax, math.Max(math.Abs(f[0]), math.Max(math.Abs(f[1]), math.Abs(f[2]))))
+		}
+		quantisedMax := int(math.Max(0, math.Min(82, math.Floor(actualMax*166-0.5))))
+		maximumValue = float64(quantisedMax+1) / 166
+		encodeBase83(&sb, quantisedMax, 1)
+	} else {
+		encodeBase83(&sb, 0, 1)
+	}
+
+	encodeBase83(&sb, linearToSRGB(dc[0])<<16|linearToSRGB(dc[1])<<8|linearToSRGB(dc[2]), 4)
+	for _, f := range ac {
+		value := 0
+		for _, c := range f {
+			quantised := int(math.Max(0, math.Min(18, math.Floor(signPow(c/maximumValue, 0.5)*9+9.5))))
+			value = value*19 + quantised
+		}
+		encodeBase83(&sb, value, 2)
+	}
+	return sb.String()
+}
+
+func encodeBase83(sb *strings.Builder, value, length int) {
+	for i := 1; i <= length; i++ {
+		digit := value / int(math.Pow(83, float64(length-i))) % 83
+		sb.WriteByte(base83Chars[digit])
+	}
+}
+
+func srgbToLinear(v uint8) float64 {
+	f := float64(v) / 255
+	if f <= 0.04045 {
+		return f / 12.92
+	}
+	return math.Pow((f+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) int {
+	v = math.Max(0, math.Min(1, v))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
@@ -0,0 +1,60 @@
+package media
+
+import "image"
+
+// CropRect returns the largest rectangle with the aspect ratio width:height inside a
+// srcW x srcH image, centered as close to the focal point (fx, fy from 0 to 1) as the
+// image edges allow
+func CropRect(srcW, srcH, width, height int, fx, fy float64) image.Rectangle {
+	cropW, cropH := srcW, srcW*height/width
+	if cropH > srcH {
+		cropW, cropH = srcH*width/height, srcH
+	}
+	cropW, cropH = max(1, cropW), max(1, cropH)
+
+	x := clamp(int(fx*float64(srcW))-cropW/2, 0, srcW-cropW)
+	y := clamp(int(fy*float64(srcH))-cropH/2, 0, srcH-cropH)
+	return image.Rect(x, y, x+cropW, y+cropH)
+}
+
+// SmartFocus estimates the focal point of an image as the centroid of its edge energy,
+// so crops keep the detailed subject rather than flat background. It returns the
+// center when the image has no edges.
+func SmartFocus(img *image.RGBA) (float64, float64) {
+	w, h := FitWithin(img.Bounds().Dx(), img.Bounds().Dy(), 64)
+	small := Resize(img, w, h)
+
+	luma := make([]int, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			luma[y*w+x] = luminance(small, x, y) / 1000
+		}
+	}
+
+	var total, sumX, sumY float64
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			dx := abs(luma[y*w+x+1] - luma[y*w+x-1])
+			dy := abs(luma[(y+1)*w+x] - luma[(y-1)*w+x])
+			energy := float64(dx + dy)
+			total += energy
+			sumX += energy * (float64(x) + 0.5)
+			sumY += energy * (float64(y) + 0.5)
+		}
+	}
+	if total == 0 {
+		return 0.5, 0.5
+	}
+	return sumX / total / float64(w), sumY / total / float64(h)
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,67 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// exifHeader prefixes the TIFF structure in JPEG APP1 segments (and some WebP EXIF chunks)
+var exifHeader = []byte("Exif\x00\x00")
+
+// exifOrientation returns the orientation tag (1-8) of a TIFF encoded EXIF block,
+// or 0 when the block has none
+func exifOrientation(tiff []byte) int {
+	tiff = bytes.TrimPrefix(tiff, exifHeader)
+	if len(tiff) < 8 {
+		return 0
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		// Orientation is tag 0x0112 of type SHORT
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// orientationOnlyEXIF builds a minimal little-endian EXIF block holding nothing but
+// the orientation tag, for formats that cannot be rotated but must drop other metadata
+func orientationOnlyEXIF(orientation int) []byte {
+	tiff := make([]byte, 26)
+	copy(tiff, "II*\x00")
+	binary.LittleEndian.PutUint32(tiff[4:], 8) // offset of IFD0
+	binary.LittleEndian.PutUint16(tiff[8:], 1) // one entry
+	binary.LittleEndian.PutUint16(tiff[10:], 0x0112)
+	binary.LittleEndian.PutUint16(tiff[12:], 3) // SHORT
+	binary.LittleEndian.PutUint32(tiff[14:], 1) // count
+	binary.LittleEndian.PutUint16(tiff[18:], uint16(orientation))
+	binary.LittleEndian.PutUint32(tiff[22:], 0) // no next IFD
+	return tiff
+}
@@ -0,0 +1,105 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	// Register the decoders used by DecodeImage
+	_ "image/gif"
+)
+
+// MaxDecodePixels limits the dimensions of images that are decoded, so a small
+// file with huge dimensions cannot exhaust memory
+const MaxDecodePixels = 100_000_000
+
+// DecodeImage decodes a JPEG, PNG or GIF image after checking its dimensions
+func DecodeImage(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image header: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > MaxDecodePixels {
+		return nil, fmt.Errorf("image dimensions %dx%d are not supported", cfg.Width, cfg.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// FitWithin returns the dimensions of a width x height image scaled down so its
+// longest edge is at most size. Images that already fit are not scaled.
+func FitWithin(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
+
+// Resize scales img to width x height. Each destination pixel is the average of the
+// source pixels it covers (box filter), which gives clean results when downscaling.
+func Resize(img image.Image, width, height int) *image.RGBA {
+	src := ToRGBA(img)
+	srcW, srcH := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := y * srcH / height
+		y1 := max(y0+1, (y+1)*srcH/height)
+		for x := 0; x < width; x++ {
+			x0 := x * srcW / width
+			x1 := max(x0+1, (x+1)*srcW/width)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					b += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// EncodeImage writes img as JPEG when it is opaque and as PNG otherwise, and
+// returns the content type and file extension used
+func EncodeImage(w io.Writer, img *image.RGBA, quality int) (string, string, error) {
+	if img.Opaque() {
+		return "image/jpeg", ".jpg", jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	}
+	return "image/png", ".png", png.Encode(w, img)
+}
+
+// ToRGBA converts img to a premultiplied RGBA image whose bounds start at 0,0.
+// Converting once up front avoids repeating the conversion for every Resize.
+func ToRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
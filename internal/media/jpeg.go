@@ -0,0 +1,96 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var errInvalidJPEG = errors.New("invalid JPEG structure")
+
+// jpegSegment is a marker segment before the image data, spanning data[start:end]
+type jpegSegment struct {
+	marker     byte
+	start, end int
+}
+
+func (s jpegSegment) payload(data []byte) []byte {
+	if s.end-s.start < 4 {
+		return nil
+	}
+	return data[s.start+4 : s.end]
+}
+
+// jpegSegments lists the marker segments of a JPEG file up to the start of scan,
+// and returns the offset of the start-of-scan marker
+func jpegSegments(data []byte) ([]jpegSegment, int, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, 0, errInvalidJPEG
+	}
+
+	var segments []jpegSegment
+	i := 2
+	for {
+		if i+2 > len(data) || data[i] != 0xFF {
+			return nil, 0, errInvalidJPEG
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF:
+			// Fill byte before a marker
+			i++
+			continue
+		case marker == 0xDA || marker == 0xD9:
+			// Start of scan or end of image: the rest is image data
+			return segments, i, nil
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			// Markers without a length
+			segments = append(segments, jpegSegment{marker: marker, start: i, end: i + 2})
+			i += 2
+			continue
+		}
+
+		if i+4 > len(data) {
+			return nil, 0, errInvalidJPEG
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end < i+4 || end > len(data) {
+			return nil, 0, errInvalidJPEG
+		}
+		segments = append(segments, jpegSegment{marker: marker, start: i, end: end})
+		i = end
+	}
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG file, or 0 when it has none
+func jpegOrientation(data []byte) int {
+	segments, _, err := jpegSegments(data)
+	if err != nil {
+		return 0
+	}
+	for _, segment := range segments {
+		if payload := segment.payload(data); segment.marker == 0xE1 && bytes.HasPrefix(payload, exifHeader) {
+			return exifOrientation(payload)
+		}
+	}
+	return 0
+}
+
+// stripJPEGMetadata removes the APP1 (EXIF, XMP) and APP13 (IPTC) segments from a JPEG
+// file without re-encoding it. Color profiles and the image data are kept as they are.
+func stripJPEGMetadata(data []byte) ([]byte, error) {
+	segments, scan, err := jpegSegments(data)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+	for _, segment := range segments {
+		if segment.marker == 0xE1 || segment.marker == 0xED {
+			continue
+		}
+		out = append(out, data[segment.start:segment.end]...)
+	}
+	return append(out, data[scan:]...), nil
+}
@@ -0,0 +1,172 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+)
+
+// ImageInfo describes the pixel dimensions and color model of an image
+type ImageInfo struct {
+	Width  int
+	Height int
+	// Orientation is the EXIF orientation (1-8); 1 means the image is stored upright
+	Orientation int
+	ColorModel  string
+}
+
+// IsDecodable reports whether DecodeImage can decode images of contentType
+func IsDecodable(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
+
+// ProbeImage reads the dimensions, EXIF orientation and color model of a JPEG, PNG,
+// GIF or WebP image without decoding its pixels
+func ProbeImage(data []byte, contentType string) (*ImageInfo, error) {
+	if contentType == "image/webp" {
+		info, err := probeWebP(data)
+		if err != nil {
+			return nil, err
+		}
+		if info.Orientation == 0 {
+			info.Orientation = 1
+		}
+		return info, nil
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image header: %w", err)
+	}
+	info := &ImageInfo{
+		Width:      cfg.Width,
+		Height:     cfg.Height,
+		ColorModel: colorModelName(cfg.ColorModel),
+	}
+	switch contentType {
+	case "image/jpeg":
+		info.Orientation = jpegOrientation(data)
+	case "image/png":
+		info.Orientation = pngOrientation(data)
+	}
+	if info.Orientation == 0 {
+		info.Orientation = 1
+	}
+	return info, nil
+}
+
+// SanitizeImage removes EXIF metadata (including GPS positions) and applies the EXIF
+// orientation, so the result is stored upright without camera metadata:
+//   - JPEGs that need rotating are re-encoded with quality; others only lose their
+//     metadata segments, without re-encoding
+//   - PNGs that need rotating are re-encoded losslessly; others lose their eXIf chunk
+//   - WebPs lose their EXIF and XMP chunks but keep a non-default orientation
+//   - GIFs carry no EXIF and are returned unchanged
+func SanitizeImage(data []byte, contentType string, orientation int, quality int) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		if orientation > 1 {
+			return reorient(data, orientation, func(buf *bytes.Buffer, img image.Image) error {
+				return jpeg.Encode(buf, img, &jpeg.Options{Quality: quality})
+			})
+		}
+		return stripJPEGMetadata(data)
+	case "image/png":
+		if orientation > 1 {
+			return reorient(data, orientation, func(buf *bytes.Buffer, img image.Image) error {
+				return png.Encode(buf, img)
+			})
+		}
+		return stripPNGMetadata(data)
+	case "image/webp":
+		return stripWebPMetadata(data, orientation)
+	}
+	return data, nil
+}
+
+// reorient decodes an image, rotates it upright and encodes it again. The encoders
+// of the standard library never write EXIF, so the result carries no metadata.
+func reorient(data []byte, orientation int, encode func(*bytes.Buffer, image.Image) error) ([]byte, error) {
+	img, err := DecodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := encode(&buf, Orient(ToRGBA(img), orientation)); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Orient transforms an image stored with the given EXIF orientation so it is upright.
+// Orientations 5 to 8 swap width and height.
+func Orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+
+	srcW, srcH := src.Bounds().Dx(), src.Bounds().Dy()
+	dstW, dstH := srcW, srcH
+	if orientation >= 5 {
+		dstW, dstH = srcH, srcW
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+
+	for y := 0; y < dstH; y++ {
+		for x := 0; x < dstW; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored horizontally
+				sx, sy = srcW-1-x, y
+			case 3: // rotated 180°
+				sx, sy = srcW-1-x, srcH-1-y
+			case 4: // mirrored vertically
+				sx, sy = x, srcH-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // needs 90° clockwise rotation
+				sx, sy = y, srcH-1-x
+			case 7: // transversed
+				sx, sy = srcW-1-y, srcH-1-x
+			case 8: // needs 90° counter-clockwise rotation
+				sx, sy = srcW-1-y, x
+			}
+			copy(dst.Pix[y*dst.Stride+x*4:y*dst.Stride+x*4+4], src.Pix[sy*src.Stride+sx*4:])
+		}
+	}
+	return dst
+}
+
+// colorModelName names the color models used by the standard decoders
+func colorModelName(model color.Model) string {
+	switch model {
+	case color.YCbCrModel:
+		return "YCbCr"
+	case color.CMYKModel:
+		return "CMYK"
+	case color.GrayModel:
+		return "Gray"
+	case color.Gray16Model:
+		return "Gray16"
+	case color.RGBAModel:
+		return "RGBA"
+	case color.RGBA64Model:
+		return "RGBA64"
+	case color.NRGBAModel:
+		return "NRGBA"
+	case color.NRGBA64Model:
+		return "NRGBA64"
+	}
+	if _, ok := model.(color.Palette); ok {
+		return "Paletted"
+	}
+	return "unknown"
+}
@@ -0,0 +1,283 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ErrCorruptContainer is returned when an MP4/MOV file does not have a valid box structure
+var ErrCorruptContainer = errors.New("corrupt video container")
+
+// maxMoovSize limits how much of the movie header is read into memory
+const maxMoovSize = 64 << 20
+
+// VideoInfo describes the first video track of an MP4/MOV file
+type VideoInfo struct {
+	// Duration of the movie in seconds
+	Duration  float64
+	Width     int
+	Height    int
+	FrameRate float64
+	// Codec is the sample entry FourCC, e.g. avc1, hvc1 or av01
+	Codec string
+}
+
+// IsProbeable reports whether ProbeMP4 can read videos of contentType
+func IsProbeable(contentType string) bool {
+	return contentType == "video/mp4" || contentType == "video/quicktime"
+}
+
+// ProbeMP4 parses the ISO base media file format boxes (moov/mvhd/trak/tkhd/mdia/stsd/stts)
+// of an MP4 or QuickTime file. Only the top-level box headers and the moov box are read,
+// so r can be backed by ranged storage reads.
+func ProbeMP4(r io.ReaderAt, size int64) (*VideoInfo, error) {
+	moov, err := findMoov(r, size)
+	if err != nil {
+		return nil, err
+	}
+	children, err := parseBoxes(moov)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &VideoInfo{}
+	foundVideo := false
+	for _, child := range children {
+		switch child.kind {
+		case "mvhd":
+			timescale, duration, err := parseTimes(child.body)
+			if err != nil {
+				return nil, err
+			}
+			if timescale > 0 {
+				info.Duration = float64(duration) / float64(timescale)
+			}
+		case "trak":
+			if foundVideo {
+				continue
+			}
+			found, err := parseVideoTrack(child.body, info)
+			if err != nil {
+				return nil, err
+			}
+			foundVideo = found
+		}
+	}
+
+	if !foundVideo {
+		return nil, fmt.Errorf("%w: no video track", ErrCorruptContainer)
+	}
+	info.Duration = round3(info.Duration)
+	info.FrameRate = round3(info.FrameRate)
+	return info, nil
+}
+
+type mp4Box struct {
+	kind string
+	body []byte
+}
+
+// findMoov walks the top-level boxes and reads the moov box
+func findMoov(r io.ReaderAt, size int64) ([]byte, error) {
+	header := make([]byte, 16)
+	for offset := int64(0); offset < size; {
+		if size-offset < 8 {
+			return nil, fmt.Errorf("%w: truncated box header", ErrCorruptContainer)
+		}
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return nil, err
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header))
+		kind := string(header[4:8])
+		headerSize := int64(8)
+
+		switch boxSize {
+		case 0:
+			// The last box extends to the end of the file
+			boxSize = size - offset
+		case 1:
+			if size-offset < 16 {
+				return nil, fmt.Errorf("%w: truncated box header", ErrCorruptContainer)
+			}
+			if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+				return nil, err
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if boxSize < headerSize || boxSize > size-offset {
+			return nil, fmt.Errorf("%w: box %q has an invalid size", ErrCorruptContainer, kind)
+		}
+
+		if kind == "moov" {
+			if boxSize-headerSize > maxMoovSize {
+				return nil, fmt.Errorf("%w: moov box is too large", ErrCorruptContainer)
+			}
+			moov := make([]byte, boxSize-headerSize)
+			if _, err := r.ReadAt(moov, offset+headerSize); err != nil && !(errors.Is(err, io.EOF) && offset+boxSize == size) {
+				return nil, err
+			}
+			return moov, nil
+		}
+		offset += boxSize
+	}
+	return nil, fmt.Errorf("%w: no moov box", ErrCorruptContainer)
+}
+
+// parseBoxes splits data into consecutive boxes
+func parseBoxes(data []byte) ([]mp4Box, error) {
+	var boxes []mp4Box
+	for len(data) > 0 {
+		if len(data) < 8 {
+			// QuickTime allows a 32-bit zero terminator at the end of a container
+			if len(data) == 4 && binary.BigEndian.Uint32(data) == 0 {
+				break
+			}
+			return nil, fmt.Errorf("%w: truncated box header", ErrCorruptContainer)
+		}
+		size := uint64(binary.BigEndian.Uint32(data))
+		kind := string(data[4:8])
+		headerSize := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, fmt.Errorf("%w: truncated box header", ErrCorruptContainer)
+			}
+			size = binary.BigEndian.Uint64(data[8:])
+			headerSize = 16
+		}
+		if size < headerSize || size > uint64(len(data)) {
+			return nil, fmt.Errorf("%w: box %q has an invalid size", ErrCorruptContainer, kind)
+		}
+		boxes = append(boxes, mp4Box{kind: kind, body: data[headerSize:size]})
+		data = data[size:]
+	}
+	return boxes, nil
+}
+
+// findBox returns the body of the first box of the given path, e.g. "mdia", "minf", "stbl"
+func findBox(data []byte, path ...string) ([]byte, error) {
+	for _, kind := range path {
+		boxes, err := parseBoxes(data)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, b := range boxes {
+			if b.kind == kind {
+				data, found = b.body, true
+				break
+			}
+		}
+		if !found {
+			return nil, nil
+		}
+	}
+	return data, nil
+}
+
+// parseVideoTrack fills info from a trak box and reports whether it is a video track
+func parseVideoTrack(trak []byte, info *VideoInfo) (bool, error) {
+	mdia, err := findBox(trak, "mdia")
+	if err != nil || mdia == nil {
+		return false, err
+	}
+	hdlr, err := findBox(mdia, "hdlr")
+	if err != nil {
+		return false, err
+	}
+	// version/flags, pre_defined (component type in QuickTime), handler type
+	if len(hdlr) < 12 || string(hdlr[8:12]) != "vide" {
+		return false, nil
+	}
+
+	// Display size from the track header, as 16.16 fixed point
+	tkhd, err := findBox(trak, "tkhd")
+	if err != nil {
+		return false, err
+	}
+	if tkhd != nil {
+		offset := 76 // v0: times and ids, reserved, layer, group, volume and matrix
+		if len(tkhd) > 0 && tkhd[0] == 1 {
+			offset = 88
+		}
+		if len(tkhd) < offset+8 {
+			return false, fmt.Errorf("%w: truncated tkhd box", ErrCorruptContainer)
+		}
+		info.Width = int(binary.BigEndian.Uint32(tkhd[offset:]) >> 16)
+		info.Height = int(binary.BigEndian.Uint32(tkhd[offset+4:]) >> 16)
+	}
+
+	var trackSeconds float64
+	if mdhd, err := findBox(mdia, "mdhd"); err != nil {
+		return false, err
+	} else if mdhd != nil {
+		timescale, duration, err := parseTimes(mdhd)
+		if err != nil {
+			return false, err
+		}
+		if timescale > 0 {
+			trackSeconds = float64(duration) / float64(timescale)
+		}
+	}
+	if info.Duration == 0 {
+		info.Duration = trackSeconds
+	}
+
+	stbl, err := findBox(mdia, "minf", "stbl")
+	if err != nil || stbl == nil {
+		return true, err
+	}
+
+	// Codec FourCC and coded size from the first sample description
+	if stsd, err := findBox(stbl, "stsd"); err != nil {
+		return false, err
+	} else if len(stsd) >= 16 {
+		entry := stsd[8:]
+		info.Codec = string(entry[4:8])
+		// reserved, data reference index, pre_defined and reserved, then width and height
+		if (info.Width == 0 || info.Height == 0) && len(entry) >= 36 {
+			info.Width = int(binary.BigEndian.Uint16(entry[32:]))
+			info.Height = int(binary.BigEndian.Uint16(entry[34:]))
+		}
+	}
+
+	// Frame rate from the number of samples in the time-to-sample table
+	if stts, err := findBox(stbl, "stts"); err != nil {
+		return false, err
+	} else if len(stts) >= 8 && trackSeconds > 0 {
+		entries := int(binary.BigEndian.Uint32(stts[4:]))
+		if len(stts) < 8+entries*8 {
+			return false, fmt.Errorf("%w: truncated stts box", ErrCorruptContainer)
+		}
+		var samples uint64
+		for i := 0; i < entries; i++ {
+			samples += uint64(binary.BigEndian.Uint32(stts[8+i*8:]))
+		}
+		info.FrameRate = float64(samples) / trackSeconds
+	}
+	return true, nil
+}
+
+// parseTimes reads the timescale and duration of an mvhd or mdhd box
+func parseTimes(body []byte) (uint32, uint64, error) {
+	if len(body) > 0 && body[0] == 1 {
+		if len(body) < 32 {
+			return 0, 0, fmt.Errorf("%w: truncated header box", ErrCorruptContainer)
+		}
+		return binary.BigEndian.Uint32(body[20:]), binary.BigEndian.Uint64(body[24:]), nil
+	}
+	if len(body) < 20 {
+		return 0, 0, fmt.Errorf("%w: truncated header box", ErrCorruptContainer)
+	}
+	return binary.BigEndian.Uint32(body[12:]), uint64(binary.BigEndian.Uint32(body[16:])), nil
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
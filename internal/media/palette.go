@@ -0,0 +1,85 @@
+package media
+
+import (
+	"fmt"
+	"image"
+	"sort"
+)
+
+const (
+	// paletteSampleSize is the longest edge images are reduced to before counting colors
+	paletteSampleSize = 64
+	// paletteMinDistance is the squared RGB distance below which two palette colors
+	// count as the same shade
+	paletteMinDistance = 48 * 48
+)
+
+// Palette returns up to n dominant colors of an image as "#rrggbb", most frequent first.
+// Colors are counted in buckets of 16 levels per channel; transparent pixels are ignored.
+func Palette(img *image.RGBA, n int) []string {
+	w, h := FitWithin(img.Bounds().Dx(), img.Bounds().Dy(), paletteSampleSize)
+	small := Resize(img, w, h)
+
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+	buckets := map[int]*bucket{}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := small.Pix[y*small.Stride+x*4:]
+			a := int(p[3])
+			if a < 128 {
+				continue
+			}
+			// image.RGBA is alpha-premultiplied
+			r, g, b := int(p[0])*255/a, int(p[1])*255/a, int(p[2])*255/a
+			key := r>>4<<8 | g>>4<<4 | b>>4
+			bk := buckets[key]
+			if bk == nil {
+				bk = &bucket{}
+				buckets[key] = bk
+			}
+			bk.count++
+			bk.r += r
+			bk.g += g
+			bk.b += b
+		}
+	}
+
+	sorted := make([]*bucket, 0, len(buckets))
+	for _, bk := range buckets {
+		sorted = append(sorted, bk)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		return sorted[i].r+sorted[i].g+sorted[i].b < sorted[j].r+sorted[j].g+sorted[j].b
+	})
+
+	colors := []string{}
+	picked := [][3]int{}
+	for _, bk := range sorted {
+		if len(colors) == n {
+			break
+		}
+		c := [3]int{bk.r / bk.count, bk.g / bk.count, bk.b / bk.count}
+		if nearColor(picked, c) {
+			continue
+		}
+		picked = append(picked, c)
+		colors = append(colors, fmt.Sprintf("#%02x%02x%02x", c[0], c[1], c[2]))
+	}
+	return colors
+}
+
+func nearColor(colors [][3]int, c [3]int) bool {
+	for _, other := range colors {
+		dr, dg, db := c[0]-other[0], c[1]-other[1], c[2]-other[2]
+		if dr*dr+dg*dg+db*db < paletteMinDistance {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package media
+
+import (
+	"fmt"
+	"image"
+	"math/bits"
+	"strconv"
+)
+
+// DHash computes the 64-bit difference hash of an image: the image is reduced to 9x8
+// gray pixels and each bit records whether a pixel is brighter than its right neighbour.
+// Resized, re-compressed or slightly edited copies keep nearly the same hash.
+func DHash(img *image.RGBA) uint64 {
+	small := Resize(img, 9, 8)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if luminance(small, x, y) > luminance(small, x+1, y) {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// FormatHash encodes a perceptual hash as 16 hex digits
+func FormatHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// ParseHash decodes a hash encoded by FormatHash
+func ParseHash(s string) (uint64, error) {
+	return strconv.ParseUint(s, 16, 64)
+}
+
+// HammingDistance returns the number of differing bits of two hashes (0-64)
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+func luminance(img *image.RGBA, x, y int) int {
+	p := img.Pix[y*img.Stride+x*4:]
+	return 299*int(p[0]) + 587*int(p[1]) + 114*int(p[2])
+}
@@ -0,0 +1,76 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	pngSignature  = []byte("\x89PNG\r\n\x1a\n")
+	errInvalidPNG = errors.New("invalid PNG structure")
+)
+
+// pngChunk is a chunk of a PNG file, spanning data[start:end] including length and CRC
+type pngChunk struct {
+	kind       string
+	start, end int
+}
+
+// pngChunks lists the chunks of a PNG file up to IEND. Bytes after IEND, which some
+// encoders and editors append, are ignored like decoders do and are not listed.
+func pngChunks(data []byte) ([]pngChunk, error) {
+	if len(data) < len(pngSignature) || string(data[:len(pngSignature)]) != string(pngSignature) {
+		return nil, errInvalidPNG
+	}
+
+	var chunks []pngChunk
+	for i := len(pngSignature); i < len(data); {
+		if i+12 > len(data) {
+			return nil, errInvalidPNG
+		}
+		end := i + 12 + int(binary.BigEndian.Uint32(data[i:]))
+		if end < i+12 || end > len(data) {
+			return nil, errInvalidPNG
+		}
+		chunk := pngChunk{kind: string(data[i+4 : i+8]), start: i, end: end}
+		chunks = append(chunks, chunk)
+		if chunk.kind == "IEND" {
+			break
+		}
+		i = end
+	}
+	return chunks, nil
+}
+
+// pngOrientation returns the orientation of the eXIf chunk of a PNG file, or 0 when it has none
+func pngOrientation(data []byte) int {
+	chunks, err := pngChunks(data)
+	if err != nil {
+		return 0
+	}
+	for _, chunk := range chunks {
+		if chunk.kind == "eXIf" {
+			return exifOrientation(data[chunk.start+8 : chunk.end-4])
+		}
+	}
+	return 0
+}
+
+// stripPNGMetadata removes the eXIf chunk and any trailing bytes after IEND from a PNG
+// file. Each chunk has its own CRC, so the remaining chunks stay valid.
+func stripPNGMetadata(data []byte) ([]byte, error) {
+	chunks, err := pngChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	for _, chunk := range chunks {
+		if chunk.kind == "eXIf" {
+			continue
+		}
+		out = append(out, data[chunk.start:chunk.end]...)
+	}
+	return out, nil
+}
@@ -0,0 +1,177 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"mime"
+	"strings"
+)
+
+// SniffLength is the number of leading bytes DetectContentType looks at
+const SniffLength = 512
+
+// DetectContentType identifies the media type of a file from its leading bytes
+// (file signatures / magic numbers). It returns "" when the format is not recognised.
+func DetectContentType(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte{0xFF, 0xD8, 0xFF}):
+		return "image/jpeg"
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png"
+	case bytes.HasPrefix(header, []byte("GIF87a")), bytes.HasPrefix(header, []byte("GIF89a")):
+		return "image/gif"
+	case len(header) >= 12 && bytes.HasPrefix(header, []byte("RIFF")):
+		switch string(header[8:12]) {
+		case "WEBP":
+			return "image/webp"
+		case "AVI ":
+			return "video/x-msvideo"
+		}
+	case len(header) >= 8 && isTopLevelAtom(string(header[4:8])):
+		// ISO base media (MP4) and QuickTime files start with a box
+		return isoContentType(header)
+	case bytes.HasPrefix(header, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		// EBML container; the DocType element distinguishes WebM from Matroska
+		if bytes.Contains(header, []byte("webm")) {
+			return "video/webm"
+		}
+		return "video/x-matroska"
+	case bytes.HasPrefix(header, []byte{0x00, 0x00, 0x01, 0xBA}), bytes.HasPrefix(header, []byte{0x00, 0x00, 0x01, 0xB3}):
+		// MPEG program stream pack header or video sequence header
+		return "video/mpeg"
+	}
+	return ""
+}
+
+// NormalizeContentType strips parameters and maps common aliases to their canonical type
+func NormalizeContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	switch mediaType {
+	case "image/jpg", "image/pjpeg":
+		return "image/jpeg"
+	case "video/avi", "video/msvideo":
+		return "video/x-msvideo"
+	}
+	return mediaType
+}
+
+// IsGenericContentType reports whether contentType carries no real type information,
+// in which case the detected type is used without a mismatch check
+func IsGenericContentType(contentType string) bool {
+	switch NormalizeContentType(contentType) {
+	case "", "application/octet-stream", "binary/octet-stream":
+		return true
+	}
+	return false
+}
+
+// IsImage reports whether contentType is an image type
+func IsImage(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/")
+}
+
+// IsVideo reports whether contentType is a video type
+func IsVideo(contentType string) bool {
+	return strings.HasPrefix(contentType, "video/")
+}
+
+// isoContentType walks the top-level boxes of an ISO base media or QuickTime file until
+// it reaches an ftyp or moov box. Legacy QuickTime files have no ftyp box and are only
+// trusted once their moov atom is found, since names like free or mdat alone prove nothing.
+func isoContentType(header []byte) string {
+	for offset := 0; offset+8 <= len(header); {
+		size := uint64(binary.BigEndian.Uint32(header[offset:]))
+		name := string(header[offset+4 : offset+8])
+		switch name {
+		case "ftyp":
+			return ftypContentType(header[offset:])
+		case "moov":
+			return "video/quicktime"
+		}
+		if !isTopLevelAtom(name) {
+			return ""
+		}
+
+		if size == 1 {
+			// 64-bit box size follows the name
+			if offset+16 > len(header) {
+				return ""
+			}
+			size = binary.BigEndian.Uint64(header[offset+8:])
+		}
+		if size < 8 || size > uint64(len(header)-offset) {
+			return ""
+		}
+		offset += int(size)
+	}
+	return ""
+}
+
+// ftypContentType decides the media type from the major and compatible brands of an
+// ftyp box, or returns "" when no MP4 or QuickTime video brand is listed. ISO media also
+// carries HEIC/AVIF images, 3GP and audio-only files; those are rejected by their major
+// brand even when they list MP4 brands as compatible.
+func ftypContentType(box []byte) string {
+	if len(box) < 12 {
+		return ""
+	}
+	end := len(box)
+	if size := int(binary.BigEndian.Uint32(box)); size >= 16 && size < end {
+		end = size
+	}
+
+	major := string(box[8:12])
+	if major == "qt  " {
+		return "video/quicktime"
+	}
+	if isNonVideoBrand(major) {
+		return ""
+	}
+
+	// The minor version (4 bytes) separates the major brand from the compatible brands
+	brands := []string{major}
+	for i := 16; i+4 <= end; i += 4 {
+		brands = append(brands, string(box[i:i+4]))
+	}
+	for _, brand := range brands {
+		if isMP4Brand(brand) {
+			return "video/mp4"
+		}
+	}
+	for _, brand := range brands {
+		if brand == "qt  " {
+			return "video/quicktime"
+		}
+	}
+	return ""
+}
+
+// isMP4Brand reports whether brand marks an MP4 file we can probe, including fragmented
+// MP4 (iso4-iso9, dash) as written by ffmpeg -movflags
+func isMP4Brand(brand string) bool {
+	switch brand {
+	case "isom", "avc1", "mp41", "mp42", "mp71", "dash", "msnv", "M4V ", "M4VH", "M4VP", "f4v ":
+		return true
+	}
+	return len(brand) == 4 && strings.HasPrefix(brand, "iso") && brand[3] >= '2' && brand[3] <= '9'
+}
+
+// isNonVideoBrand reports whether a major brand marks an image, 3GPP or audio-only file
+func isNonVideoBrand(brand string) bool {
+	switch brand {
+	case "heic", "heix", "heim", "heis", "hevc", "hevx", "mif1", "msf1", "avif", "avis", "M4A ", "M4B ", "M4P ":
+		return true
+	}
+	return strings.HasPrefix(brand, "3gp") || strings.HasPrefix(brand, "3g2")
+}
+
+func isTopLevelAtom(name string) bool {
+	switch name {
+	case "ftyp", "moov", "mdat", "wide", "free", "skip", "pnot":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errInvalidWebP = errors.New("invalid WebP structure")
+
+// webpChunk is a chunk of a WebP file; its payload is data[start+8 : start+8+size]
+type webpChunk struct {
+	fourCC string
+	start  int
+	size   int
+}
+
+func (c webpChunk) payload(data []byte) []byte {
+	return data[c.start+8 : c.start+8+c.size]
+}
+
+// webpChunks lists the chunks inside the RIFF container of a WebP file
+func webpChunks(data []byte) ([]webpChunk, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errInvalidWebP
+	}
+
+	var chunks []webpChunk
+	for i := 12; i+8 <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		if size < 0 || i+8+size > len(data) {
+			return nil, errInvalidWebP
+		}
+		chunks = append(chunks, webpChunk{fourCC: string(data[i : i+4]), start: i, size: size})
+		// Chunks are padded to an even size
+		i += 8 + size + size&1
+	}
+	if len(chunks) == 0 {
+		return nil, errInvalidWebP
+	}
+	return chunks, nil
+}
+
+// probeWebP reads the canvas size, color model and EXIF orientation of a WebP file.
+// The standard library has no WebP decoder, so the bitstream headers are parsed directly.
+func probeWebP(data []byte) (*ImageInfo, error) {
+	chunks, err := webpChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ImageInfo{ColorModel: "YCbCr"}
+	extended := false
+	for _, chunk := range chunks {
+		payload := chunk.payload(data)
+		switch chunk.fourCC {
+		case "VP8X":
+			if len(payload) < 10 {
+				return nil, errInvalidWebP
+			}
+			extended = true
+			info.Width = 1 + int(uint32(payload[4])|uint32(payload[5])<<8|uint32(payload[6])<<16)
+			info.Height = 1 + int(uint32(payload[7])|uint32(payload[8])<<8|uint32(payload[9])<<16)
+			if payload[0]&0x10 != 0 {
+				info.ColorModel = "NRGBA"
+			}
+		case "VP8 ":
+			// Lossy: frame tag, start code 9d 01 2a, then 14-bit width and height
+			if len(payload) < 10 || payload[3] != 0x9d || payload[4] != 0x01 || payload[5] != 0x2a {
+				return nil, errInvalidWebP
+			}
+			if !extended {
+				info.Width = int(binary.LittleEndian.Uint16(payload[6:]) & 0x3fff)
+				info.Height = int(binary.LittleEndian.Uint16(payload[8:]) & 0x3fff)
+			}
+		case "VP8L":
+			// Lossless: signature 0x2f, then 14-bit width-1, 14-bit height-1 and the alpha hint
+			if len(payload) < 5 || payload[0] != 0x2f {
+				return nil, errInvalidWebP
+			}
+			bits := binary.LittleEndian.Uint32(payload[1:])
+			if !extended {
+				info.Width = int(bits&0x3fff) + 1
+				info.Height = int(bits>>14&0x3fff) + 1
+			}
+			info.ColorModel = "NRGBA"
+		case "EXIF":
+			info.Orientation = exifOrientation(payload)
+		}
+	}
+
+	if info.Width == 0 || info.Height == 0 {
+		return nil, errInvalidWebP
+	}
+	return info, nil
+}
+
+// stripWebPMetadata removes the EXIF and XMP chunks of a WebP file. WebP cannot be
+// re-encoded here, so a non-default orientation is kept in a minimal EXIF chunk.
+func stripWebPMetadata(data []byte, orientation int) ([]byte, error) {
+	chunks, err := webpChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	vp8x := -1
+	for _, chunk := range chunks {
+		if chunk.fourCC == "EXIF" || chunk.fourCC == "XMP " {
+			continue
+		}
+		if chunk.fourCC == "VP8X" {
+			vp8x = len(out)
+		}
+		out = appendWebPChunk(out, chunk.fourCC, chunk.payload(data))
+	}
+
+	if vp8x >= 0 {
+		// Clear the EXIF (0x08) and XMP (0x04) flags
+		out[vp8x+8] &^= 0x0c
+		if orientation > 1 {
+			out = appendWebPChunk(out, "EXIF", orientationOnlyEXIF(orientation))
+			out[vp8x+8] |= 0x08
+		}
+	}
+
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
+
+func appendWebPChunk(out []byte, fourCC string, payload []byte) []byte {
+	out = append(out, fourCC...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(payload)))
+	out = append(out, payload...)
+	if len(payload)&1 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
@@ -10,27 +10,70 @@ import (
 type AssetStatus string
 
 const (
+	AssetStatusPending       AssetStatus = "pending"
 	AssetStatusUploaded      AssetStatus = "uploaded"
 	AssetStatusProcessed     AssetStatus = "processed"
 	AssetStatusProcessFailed AssetStatus = "process_failed"
 	AssetStatusUploadFailed  AssetStatus = "upload_failed"
+	// AssetStatusPendingProcessing marks a stored upload that still has to be moderated and
+	// get its thumbnails, renditions and hashes; it becomes uploaded afterwards
+	AssetStatusPendingProcessing AssetStatus = "pending_processing"
 )
 
 // Asset represents the asset metadata model
 type Asset struct {
-	ID          uint           `gorm:"primaryKey" json:"id"`
-	FileName    string         `gorm:"size:255;not null" json:"file_name"`
-	FileSize    int64          `gorm:"not null" json:"file_size"`
-	ContentType string         `gorm:"size:100;not null" json:"content_type"`
-	S3Key       string         `gorm:"size:500;not null;unique" json:"s3_key"`
-	OutputS3Key *string        `gorm:"size:500" json:"output_s3_key,omitempty"`
-	S3Bucket    string         `gorm:"size:255;not null" json:"s3_bucket"`
-	Status      AssetStatus    `gorm:"size:50;not null;default:'uploaded'" json:"status"`
-	UploadedAt  time.Time      `gorm:"autoCreateTime" json:"uploaded_at"`
-	ProcessedAt *time.Time     `json:"processed_at,omitempty"`
-	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	FileName        string         `gorm:"size:255;not null" json:"file_name"`
+	FileSize        int64          `gorm:"not null" json:"file_size"`
+	ContentType     string         `gorm:"size:100;not null" json:"content_type"`
+	S3Key           string         `gorm:"size:500;not null;unique" json:"s3_key"`
+	OutputS3Key     *string        `gorm:"size:500" json:"output_s3_key,omitempty"`
+	S3Bucket        string         `gorm:"size:255;not null" json:"s3_bucket"`
+	SHA256          string         `gorm:"column:sha256;size:64;index" json:"sha256,omitempty"`
+	OriginalSHA256  string         `gorm:"column:original_sha256;size:64;index" json:"original_sha256,omitempty"`
+	Width           int            `json:"width,omitempty"`
+	Height          int            `json:"height,omitempty"`
+	Orientation     int            `json:"orientation,omitempty"`
+	ColorModel      string         `gorm:"size:20" json:"color_model,omitempty"`
+	Duration        float64        `json:"duration,omitempty"`
+	FrameRate       float64        `json:"frame_rate,omitempty"`
+	VideoCodec      string         `gorm:"size:10" json:"video_codec,omitempty"`
+	PerceptualHash  string         `gorm:"size:16;index" json:"perceptual_hash,omitempty"`
+	BlurHash        string         `gorm:"size:64" json:"blurhash,omitempty"`
+	Palette         []string       `gorm:"type:json;serializer:json" json:"palette,omitempty"`
+	OutputBlurHash  string         `gorm:"size:64" json:"output_blurhash,omitempty"`
+	OutputPalette   []string       `gorm:"type:json;serializer:json" json:"output_palette,omitempty"`
+	Verdict         string         `gorm:"size:20;index" json:"verdict,omitempty"`
+	VerdictLabels   []string       `gorm:"type:json;serializer:json" json:"verdict_labels,omitempty"`
+	VerdictReason   string         `gorm:"size:500" json:"verdict_reason,omitempty"`
+	ModeratedAt     *time.Time     `json:"moderated_at,omitempty"`
+	FocalPointX     *float64       `json:"focal_point_x,omitempty"`
+	FocalPointY     *float64       `json:"focal_point_y,omitempty"`
+	Thumbnails      []Thumbnail    `gorm:"type:json;serializer:json" json:"thumbnails,omitempty"`
+	Status          AssetStatus    `gorm:"size:50;not null;default:'uploaded'" json:"status"`
+	UploadExpiresAt *time.Time     `gorm:"index" json:"upload_expires_at,omitempty"`
+	UploadedAt      time.Time      `gorm:"autoCreateTime" json:"uploaded_at"`
+	ProcessedAt     *time.Time     `json:"processed_at,omitempty"`
+	CreatedAt       time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	Renditions []AssetRendition `gorm:"foreignKey:AssetID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"renditions,omitempty"`
+
+	// DuplicateOf is set when an upload matched this existing asset instead of creating a new one
+	DuplicateOf *uint `gorm:"-" json:"duplicate_of,omitempty"`
+	// ThumbnailURLs maps each thumbnail size to a signed URL when requested by the API
+	ThumbnailURLs map[string]string `gorm:"-" json:"thumbnail_urls,omitempty"`
+}
+
+// Thumbnail is a downscaled preview of an image asset
+type Thumbnail struct {
+	// Size is the configured longest edge the thumbnail was generated for
+	Size        int    `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Key         string `json:"key"`
+	ContentType string `json:"content_type"`
 }
 
 // TableName overrides the default table name
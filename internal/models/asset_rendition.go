@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// AssetRendition is a copy of an image asset cropped and resized for a screen profile
+type AssetRendition struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	AssetID     uint      `gorm:"not null;uniqueIndex:idx_asset_rendition_profile" json:"asset_id"`
+	Profile     string    `gorm:"size:50;not null;uniqueIndex:idx_asset_rendition_profile" json:"profile"`
+	Width       int       `gorm:"not null" json:"width"`
+	Height      int       `gorm:"not null" json:"height"`
+	S3Key       string    `gorm:"size:500;not null;unique" json:"s3_key"`
+	ContentType string    `gorm:"size:100;not null" json:"content_type"`
+	FileSize    int64     `gorm:"not null" json:"file_size"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName overrides the default table name for AssetRendition
+func (AssetRendition) TableName() string {
+	return "asset_renditions"
+}
@@ -5,5 +5,11 @@ func Models() []interface{} {
 		&Asset{},
 		&Template{},
 		&Task{},
+		&UploadSession{},
+		&AssetRendition{},
+		&TaskDispatch{},
+		&TaskRun{},
+		&TaskBatch{},
+		&TaskBatchItem{},
 	}
 }
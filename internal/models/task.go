@@ -6,17 +6,82 @@ import (
 	"gorm.io/gorm"
 )
 
+// TaskStatus represents the lifecycle state of a task
+type TaskStatus string
+
+const (
+	TaskStatusPending   TaskStatus = "pending"
+	TaskStatusQueued    TaskStatus = "queued"
+	TaskStatusRunning   TaskStatus = "running"
+	TaskStatusSucceeded TaskStatus = "succeeded"
+	TaskStatusFailed    TaskStatus = "failed"
+	TaskStatusCancelled TaskStatus = "cancelled"
+	// TaskStatusRetryScheduled marks a failed task that is re-issued at NextRetryAt
+	TaskStatusRetryScheduled TaskStatus = "retry_scheduled"
+	// TaskStatusTimedOut marks a task the worker did not finish within its timeout;
+	// it waits in the dead-letter listing until it is requeued or cancelled
+	TaskStatusTimedOut TaskStatus = "timed_out"
+)
+
+// Valid reports whether s is a known task status
+func (s TaskStatus) Valid() bool {
+	switch s {
+	case TaskStatusPending, TaskStatusQueued, TaskStatusRunning, TaskStatusSucceeded, TaskStatusFailed, TaskStatusCancelled, TaskStatusRetryScheduled, TaskStatusTimedOut:
+		return true
+	}
+	return false
+}
+
+// taskTransitions lists the states each task state can move to
+var taskTransitions = map[TaskStatus][]TaskStatus{
+	TaskStatusPending:        {TaskStatusQueued, TaskStatusTimedOut, TaskStatusCancelled},
+	TaskStatusQueued:         {TaskStatusRunning, TaskStatusRetryScheduled, TaskStatusFailed, TaskStatusTimedOut, TaskStatusCancelled},
+	TaskStatusRunning:        {TaskStatusSucceeded, TaskStatusRetryScheduled, TaskStatusFailed, TaskStatusTimedOut, TaskStatusCancelled},
+	TaskStatusRetryScheduled: {TaskStatusQueued, TaskStatusPending, TaskStatusFailed, TaskStatusCancelled},
+	TaskStatusTimedOut:       {TaskStatusPending, TaskStatusSucceeded, TaskStatusCancelled},
+}
+
+// CanTransitionTo reports whether a task may move from status s to next
+func (s TaskStatus) CanTransitionTo(next TaskStatus) bool {
+	for _, allowed := range taskTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Terminal reports whether no further transitions are possible from s
+func (s TaskStatus) Terminal() bool {
+	return len(taskTransitions[s]) == 0
+}
+
 // Template represents the template metadata model
 type Task struct {
-	ID         uint                   `gorm:"primaryKey" json:"id"`
-	TemplateID uint                   `gorm:"not null" json:"template_id"`
-	Template   Template               `gorm:"foreignKey:TemplateID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"template"`
-	AssetID    uint                   `gorm:"not null" json:"asset_id"`
-	Asset      Asset                  `gorm:"foreignKey:AssetID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"asset"`
-	Metadata   map[string]interface{} `gorm:"type:json" json:"metadata,omitempty"`
-	CreatedAt  time.Time              `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt  time.Time              `gorm:"autoUpdateTime" json:"updated_at"`
-	DeletedAt  gorm.DeletedAt         `gorm:"index" json:"deleted_at,omitempty"`
+	ID          uint                   `gorm:"primaryKey" json:"id"`
+	TemplateID  uint                   `gorm:"not null;uniqueIndex:idx_task_asset_template,priority:2,where:deleted_at IS NULL" json:"template_id"`
+	Template    Template               `gorm:"foreignKey:TemplateID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"template"`
+	AssetID     uint                   `gorm:"not null;uniqueIndex:idx_task_asset_template,priority:1,where:deleted_at IS NULL" json:"asset_id"`
+	Asset       Asset                  `gorm:"foreignKey:AssetID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"asset"`
+	Metadata    map[string]interface{} `gorm:"type:json;serializer:json" json:"metadata,omitempty"`
+	Status      TaskStatus             `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	Error       string                 `gorm:"type:text" json:"error,omitempty"`
+	Attempts    int                    `gorm:"not null;default:0" json:"attempts"`
+	Run         int                    `gorm:"not null;default:1" json:"run"`
+	QueuedAt    *time.Time             `json:"queued_at,omitempty"`
+	StartedAt   *time.Time             `json:"started_at,omitempty"`
+	SucceededAt *time.Time             `json:"succeeded_at,omitempty"`
+	FailedAt    *time.Time             `json:"failed_at,omitempty"`
+	CancelledAt *time.Time             `json:"cancelled_at,omitempty"`
+	TimedOutAt  *time.Time             `json:"timed_out_at,omitempty"`
+	NextRetryAt *time.Time             `gorm:"index" json:"next_retry_at,omitempty"`
+	RequeuedAt  *time.Time             `json:"requeued_at,omitempty"`
+	CreatedAt   time.Time              `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time              `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt         `gorm:"index" json:"deleted_at,omitempty"`
+
+	Dispatches []TaskDispatch `gorm:"foreignKey:TaskID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"dispatches,omitempty"`
+	Runs       []TaskRun      `gorm:"foreignKey:TaskID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"runs,omitempty"`
 }
 
 // TableName overrides the default table name for Task
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// TaskBatchResult is the outcome of one asset and template pair of a batch
+type TaskBatchResult string
+
+const (
+	TaskBatchResultCreated  TaskBatchResult = "created"
+	TaskBatchResultExisting TaskBatchResult = "existing"
+	TaskBatchResultInvalid  TaskBatchResult = "invalid"
+)
+
+// TaskBatch records a batch of tasks created in one request, e.g. one template rendered
+// against every asset of a campaign
+type TaskBatch struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Cartesian bool      `gorm:"not null;default:false" json:"cartesian"`
+	Total     int       `gorm:"not null" json:"total"`
+	Created   int       `gorm:"not null" json:"created"`
+	Existing  int       `gorm:"not null" json:"existing"`
+	Invalid   int       `gorm:"not null" json:"invalid"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Items []TaskBatchItem `gorm:"foreignKey:BatchID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"items,omitempty"`
+}
+
+// TableName overrides the default table name for TaskBatch
+func (TaskBatch) TableName() string {
+	return "task_batches"
+}
+
+// TaskBatchItem is one asset and template pair of a batch and the task it resolved to
+type TaskBatchItem struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	BatchID    uint            `gorm:"not null;index" json:"batch_id"`
+	AssetID    uint            `gorm:"not null" json:"asset_id"`
+	TemplateID uint            `gorm:"not null" json:"template_id"`
+	TaskID     *uint           `gorm:"index" json:"task_id,omitempty"`
+	Result     TaskBatchResult `gorm:"size:20;not null" json:"result"`
+	Error      string          `gorm:"type:text" json:"error,omitempty"`
+	Violations []string        `gorm:"type:json;serializer:json" json:"violations,omitempty"`
+	// TaskStatus is the current status of the task, loaded with the batch
+	TaskStatus TaskStatus `gorm:"->;-:migration" json:"task_status,omitempty"`
+
+	// Task is set for valid pairs before the batch is stored
+	Task *Task `gorm:"-" json:"-"`
+}
+
+// TableName overrides the default table name for TaskBatchItem
+func (TaskBatchItem) TableName() string {
+	return "task_batch_items"
+}
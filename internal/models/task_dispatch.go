@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// DispatchStatus is the outcome of sending a task to the worker
+type DispatchStatus string
+
+const (
+	DispatchStatusSent   DispatchStatus = "sent"
+	DispatchStatusFailed DispatchStatus = "failed"
+)
+
+// TaskDispatch records one attempt to send a task to the rendering worker
+type TaskDispatch struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	TaskID    uint           `gorm:"not null;index" json:"task_id"`
+	Run       int            `gorm:"not null;default:1" json:"run"`
+	Attempt   int            `gorm:"not null" json:"attempt"`
+	Transport string         `gorm:"size:20;not null" json:"transport"`
+	Status    DispatchStatus `gorm:"size:20;not null" json:"status"`
+	OutputKey string         `gorm:"size:500" json:"output_key"`
+	Error     string         `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName overrides the default table name for TaskDispatch
+func (TaskDispatch) TableName() string {
+	return "task_dispatches"
+}
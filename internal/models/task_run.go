@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// TaskRun archives a finished run of a task when it is re-run
+type TaskRun struct {
+	ID          uint                   `gorm:"primaryKey" json:"id"`
+	TaskID      uint                   `gorm:"not null;uniqueIndex:idx_task_run" json:"task_id"`
+	Run         int                    `gorm:"not null;uniqueIndex:idx_task_run" json:"run"`
+	Status      TaskStatus             `gorm:"size:20;not null" json:"status"`
+	Attempts    int                    `gorm:"not null" json:"attempts"`
+	Error       string                 `gorm:"type:text" json:"error,omitempty"`
+	OutputS3Key string                 `gorm:"size:500" json:"output_s3_key,omitempty"`
+	Metadata    map[string]interface{} `gorm:"type:json;serializer:json" json:"metadata,omitempty"`
+	QueuedAt    *time.Time             `json:"queued_at,omitempty"`
+	StartedAt   *time.Time             `json:"started_at,omitempty"`
+	FinishedAt  *time.Time             `json:"finished_at,omitempty"`
+	CreatedAt   time.Time              `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName overrides the default table name for TaskRun
+func (TaskRun) TableName() string {
+	return "task_runs"
+}
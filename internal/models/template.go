@@ -8,13 +8,48 @@ import (
 
 // Template represents the template metadata model
 type Template struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Name      string         `gorm:"size:255;not null;unique" json:"name"`
-	S3Key     string         `gorm:"size:500;not null;unique" json:"s3_key"`
-	S3Bucket  string         `gorm:"size:255;not null" json:"s3_bucket"`
-	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	Name       string         `gorm:"size:255;not null;unique" json:"name"`
+	S3Key      string         `gorm:"size:500;not null;unique" json:"s3_key"`
+	S3Bucket   string         `gorm:"size:255;not null" json:"s3_bucket"`
+	Duration   float64        `json:"duration,omitempty"`
+	Width      int            `json:"width,omitempty"`
+	Height     int            `json:"height,omitempty"`
+	FrameRate  float64        `json:"frame_rate,omitempty"`
+	VideoCodec string         `gorm:"size:10" json:"video_codec,omitempty"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	Requirements TemplateRequirements `gorm:"embedded" json:"requirements"`
+	RetryPolicy  RetryPolicy          `gorm:"embedded;embeddedPrefix:retry_" json:"retry_policy"`
+	// TaskTimeout is how long a task may take after it is queued, in seconds; nil uses TASK_TIMEOUT
+	TaskTimeout *float64 `json:"task_timeout,omitempty"`
+}
+
+// TemplateRequirements restricts the assets a template can be combined with.
+// Empty lists and nil bounds accept everything.
+type TemplateRequirements struct {
+	// AcceptedMediaTypes lists content types such as "video/mp4" or wildcards such as "image/*"
+	AcceptedMediaTypes []string `gorm:"type:json;serializer:json" json:"accepted_media_types,omitempty"`
+	// AspectRatios lists accepted width:height ratios such as "16:9"
+	AspectRatios []string `gorm:"type:json;serializer:json" json:"aspect_ratios,omitempty"`
+	// MinDuration and MaxDuration bound the duration of video assets in seconds
+	MinDuration *float64 `json:"min_duration,omitempty"`
+	MaxDuration *float64 `json:"max_duration,omitempty"`
+}
+
+// RetryPolicy controls how failed tasks of a template are retried. Nil fields use the
+// TASK_RETRY_* defaults.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts *int `json:"max_attempts,omitempty"`
+	// BaseDelay is the delay before the first retry in seconds; it doubles with every attempt
+	BaseDelay *float64 `json:"base_delay,omitempty"`
+	// MaxDelay caps the delay between attempts in seconds
+	MaxDelay *float64 `json:"max_delay,omitempty"`
+	// Jitter randomizes each delay by up to this fraction (0-1)
+	Jitter *float64 `json:"jitter,omitempty"`
 }
 
 // TableName overrides the default table name for Template
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// UploadSession tracks a resumable (tus) upload whose chunks are staged in storage
+type UploadSession struct {
+	ID          string    `gorm:"primaryKey;size:36" json:"id"`
+	FileName    string    `gorm:"size:255;not null" json:"file_name"`
+	Name        string    `gorm:"size:255;not null" json:"name"`
+	ContentType string    `gorm:"size:100;not null" json:"content_type"`
+	Length      int64     `gorm:"not null" json:"length"`
+	Offset      int64     `gorm:"column:upload_offset;not null;default:0" json:"offset"`
+	Chunks      []string  `gorm:"type:json;serializer:json" json:"-"`
+	AssetID     *uint     `json:"asset_id,omitempty"`
+	Error       string    `gorm:"type:text" json:"error,omitempty"`
+	ExpiresAt   time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName overrides the default table name for UploadSession
+func (UploadSession) TableName() string {
+	return "upload_sessions"
+}
+
+// IsComplete reports whether all bytes of the upload have been received
+func (u *UploadSession) IsComplete() bool {
+	return u.Offset >= u.Length
+}
@@ -0,0 +1,71 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxResponseSize bounds the classifier response read by the HTTPModerator
+const maxResponseSize = 1 << 20
+
+// HTTPModerator posts the subject as JSON to an external classifier, which answers
+// with a verdict: {"verdict": "approve|reject|needs_review", "labels": [...], "reason": "..."}
+type HTTPModerator struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewHTTPModerator creates a moderator calling the classifier at url. apiKey is sent
+// as a bearer token when set.
+func NewHTTPModerator(url, apiKey string, timeout time.Duration) *HTTPModerator {
+	return &HTTPModerator{
+		url:    url,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Moderate asks the classifier for a verdict
+func (m *HTTPModerator) Moderate(ctx context.Context, subject Subject) (*Verdict, error) {
+	body, err := json.Marshal(subject)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("moderation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read moderation response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("moderation service returned %s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+
+	var verdict Verdict
+	if err := json.Unmarshal(data, &verdict); err != nil {
+		return nil, fmt.Errorf("invalid moderation response: %w", err)
+	}
+	if !verdict.Decision.Valid() {
+		return nil, fmt.Errorf("invalid moderation verdict %q", verdict.Decision)
+	}
+	return &verdict, nil
+}
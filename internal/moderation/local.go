@@ -0,0 +1,73 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// LocalRules configures the LocalModerator. Zero values disable a rule.
+type LocalRules struct {
+	// MaxFileSize rejects larger files (bytes)
+	MaxFileSize int64
+	// BannedHashes rejects files with one of these SHA-256 hashes (lowercase hex), matched
+	// against the file as uploaded and as stored
+	BannedHashes map[string]bool
+	// MinWidth and MinHeight send smaller images and videos to review
+	MinWidth  int
+	MinHeight int
+	// MaxWidth and MaxHeight send larger images and videos to review
+	MaxWidth  int
+	MaxHeight int
+}
+
+func (r LocalRules) banned(hash string) bool {
+	return hash != "" && r.BannedHashes[strings.ToLower(hash)]
+}
+
+// LocalModerator applies static rules without calling an external service
+type LocalModerator struct {
+	rules LocalRules
+}
+
+// NewLocalModerator creates a rule-based moderator
+func NewLocalModerator(rules LocalRules) *LocalModerator {
+	return &LocalModerator{rules: rules}
+}
+
+// Moderate rejects banned or oversized files and sends assets outside the dimension
+// bounds to review. Everything else is approved.
+func (m *LocalModerator) Moderate(ctx context.Context, subject Subject) (*Verdict, error) {
+	rules := m.rules
+	rejected := []string{}
+	review := []string{}
+	reasons := []string{}
+
+	if rules.banned(subject.OriginalSHA256) || rules.banned(subject.SHA256) {
+		rejected = append(rejected, "banned_hash")
+		reasons = append(reasons, "file is on the banned list")
+	}
+	if rules.MaxFileSize > 0 && subject.FileSize > rules.MaxFileSize {
+		rejected = append(rejected, "file_size")
+		reasons = append(reasons, fmt.Sprintf("file size %d exceeds %d bytes", subject.FileSize, rules.MaxFileSize))
+	}
+	if subject.Width > 0 && subject.Height > 0 {
+		if subject.Width < rules.MinWidth || subject.Height < rules.MinHeight {
+			review = append(review, "min_dimensions")
+			reasons = append(reasons, fmt.Sprintf("%dx%d is smaller than %dx%d", subject.Width, subject.Height, rules.MinWidth, rules.MinHeight))
+		}
+		if (rules.MaxWidth > 0 && subject.Width > rules.MaxWidth) || (rules.MaxHeight > 0 && subject.Height > rules.MaxHeight) {
+			review = append(review, "max_dimensions")
+			reasons = append(reasons, fmt.Sprintf("%dx%d is larger than %dx%d", subject.Width, subject.Height, rules.MaxWidth, rules.MaxHeight))
+		}
+	}
+
+	verdict := &Verdict{Decision: Approve, Labels: append(rejected, review...), Reason: strings.Join(reasons, "; ")}
+	switch {
+	case len(rejected) > 0:
+		verdict.Decision = Reject
+	case len(review) > 0:
+		verdict.Decision = NeedsReview
+	}
+	return verdict, nil
+}
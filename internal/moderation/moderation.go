@@ -0,0 +1,57 @@
+package moderation
+
+import (
+	"context"
+	"time"
+)
+
+// Decision is the outcome of moderating an asset
+type Decision string
+
+const (
+	Approve     Decision = "approve"
+	Reject      Decision = "reject"
+	NeedsReview Decision = "needs_review"
+)
+
+// Valid reports whether d is a known decision
+func (d Decision) Valid() bool {
+	switch d {
+	case Approve, Reject, NeedsReview:
+		return true
+	}
+	return false
+}
+
+// Verdict is the result of moderating an asset
+type Verdict struct {
+	Decision Decision `json:"verdict"`
+	// Labels name the rules or classifier categories that matched
+	Labels []string `json:"labels,omitempty"`
+	Reason string   `json:"reason,omitempty"`
+}
+
+// Subject describes the uploaded asset to moderate
+type Subject struct {
+	AssetID     uint    `json:"asset_id"`
+	FileName    string  `json:"file_name"`
+	ContentType string  `json:"content_type"`
+	FileSize    int64   `json:"file_size"`
+	SHA256      string  `json:"sha256,omitempty"`
+	Width       int     `json:"width,omitempty"`
+	Height      int     `json:"height,omitempty"`
+	Duration    float64 `json:"duration,omitempty"`
+	// URL is a signed download URL of the content, valid for URLExpiry
+	URL string `json:"url,omitempty"`
+	// OriginalSHA256 is the hash of the file as uploaded, before metadata was stripped;
+	// SHA256 is the hash of the stored file
+	OriginalSHA256 string `json:"original_sha256,omitempty"`
+}
+
+// URLExpiry is how long the content URL handed to moderators stays valid
+const URLExpiry = 15 * time.Minute
+
+// Moderator decides whether an uploaded asset may be shown in public spaces
+type Moderator interface {
+	Moderate(ctx context.Context, subject Subject) (*Verdict, error)
+}
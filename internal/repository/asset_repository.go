@@ -32,6 +32,17 @@ func (r *AssetRepository) GetByID(id uint) (*models.Asset, error) {
 	return &asset, nil
 }
 
+// FindBySHA256 returns the oldest stored asset other than excludeID whose content has the given SHA-256
+func (r *AssetRepository) FindBySHA256(sha256 string, excludeID uint) (*models.Asset, error) {
+	var asset models.Asset
+	err := r.db.Where("sha256 = ? AND status NOT IN ? AND id <> ?", sha256, []models.AssetStatus{models.AssetStatusPending, models.AssetStatusUploadFailed}, excludeID).
+		Order("id").First(&asset).Error
+	if err != nil {
+		return nil, err
+	}
+	return &asset, nil
+}
+
 // GetAll retrieves all assets with pagination
 func (r *AssetRepository) GetAll(limit, offset int) ([]models.Asset, error) {
 	var assets []models.Asset
@@ -70,3 +81,215 @@ func (r *AssetRepository) UpdateOutputS3Key(id uint, outputS3Key string) error {
 		"status":        models.AssetStatusProcessed,
 	}).Error
 }
+
+// ListExpiredPending returns pending uploads whose upload window closed before cutoff
+func (r *AssetRepository) ListExpiredPending(cutoff time.Time) ([]models.Asset, error) {
+	var assets []models.Asset
+	err := r.db.Where("status = ? AND upload_expires_at < ?", models.AssetStatusPending, cutoff).Find(&assets).Error
+	return assets, err
+}
+
+// HardDelete permanently removes an asset row
+func (r *AssetRepository) HardDelete(id uint) error {
+	return r.db.Unscoped().Delete(&models.Asset{}, id).Error
+}
+
+// UpdateThumbnails replaces the thumbnails recorded on an asset
+func (r *AssetRepository) UpdateThumbnails(id uint, thumbnails []models.Thumbnail) error {
+	return r.db.Model(&models.Asset{ID: id}).Select("thumbnails").Updates(&models.Asset{Thumbnails: thumbnails}).Error
+}
+
+// ListStorageKeys returns the input, output, thumbnail, rendition and archived run output keys of every asset, including soft-deleted ones
+func (r *AssetRepository) ListStorageKeys() ([]string, error) {
+	var assets []models.Asset
+	err := r.db.Unscoped().Select("s3_key", "output_s3_key", "thumbnails").Find(&assets).Error
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(assets))
+	for _, asset := range assets {
+		keys = append(keys, asset.S3Key)
+		if asset.OutputS3Key != nil && *asset.OutputS3Key != "" {
+			keys = append(keys, *asset.OutputS3Key)
+		}
+		for _, thumbnail := range asset.Thumbnails {
+			keys = append(keys, thumbnail.Key)
+		}
+	}
+
+	var renditionKeys []string
+	if err := r.db.Model(&models.AssetRendition{}).Pluck("s3_key", &renditionKeys).Error; err != nil {
+		return nil, err
+	}
+
+	// Outputs of earlier task runs are kept as history
+	var runKeys []string
+	if err := r.db.Model(&models.TaskRun{}).Where("output_s3_key <> ''").Pluck("output_s3_key", &runKeys).Error; err != nil {
+		return nil, err
+	}
+	return append(append(keys, renditionKeys...), runKeys...), nil
+}
+
+// ListStored returns all live assets whose files are expected to be in storage
+func (r *AssetRepository) ListStored() ([]models.Asset, error) {
+	var assets []models.Asset
+	err := r.db.Where("status NOT IN ?", []models.AssetStatus{models.AssetStatusPending, models.AssetStatusUploadFailed}).Find(&assets).Error
+	return assets, err
+}
+
+// ClearOutputS3Key removes the output reference of an asset
+func (r *AssetRepository) ClearOutputS3Key(id uint) error {
+	return r.db.Model(&models.Asset{}).Where("id = ?", id).Update("output_s3_key", nil).Error
+}
+
+// GetDeletedByID retrieves a soft-deleted asset by its ID
+func (r *AssetRepository) GetDeletedByID(id uint) (*models.Asset, error) {
+	var asset models.Asset
+	err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&asset, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// ListDeletedSince retrieves assets soft-deleted after since, most recent first
+func (r *AssetRepository) ListDeletedSince(since time.Time, limit, offset int) ([]models.Asset, error) {
+	var assets []models.Asset
+	err := r.db.Unscoped().Where("deleted_at > ?", since).
+		Order("deleted_at DESC").Limit(limit).Offset(offset).Find(&assets).Error
+	return assets, err
+}
+
+// CountDeletedSince returns the number of assets soft-deleted after since
+func (r *AssetRepository) CountDeletedSince(since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Asset{}).Where("deleted_at > ?", since).Count(&count).Error
+	return count, err
+}
+
+// ListDeletedBefore retrieves assets soft-deleted before cutoff
+func (r *AssetRepository) ListDeletedBefore(cutoff time.Time) ([]models.Asset, error) {
+	var assets []models.Asset
+	err := r.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at <= ?", cutoff).Find(&assets).Error
+	return assets, err
+}
+
+// Restore clears the soft-delete marker of an asset
+func (r *AssetRepository) Restore(id uint) error {
+	return r.db.Unscoped().Model(&models.Asset{}).Where("id = ?", id).Update("deleted_at", nil).Error
+}
+
+// GetByIDWithRenditions retrieves an asset by its ID together with its renditions
+func (r *AssetRepository) GetByIDWithRenditions(id uint) (*models.Asset, error) {
+	var asset models.Asset
+	err := r.db.Preload("Renditions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("profile")
+	}).First(&asset, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// ListRenditions returns the renditions of an asset
+func (r *AssetRepository) ListRenditions(assetID uint) ([]models.AssetRendition, error) {
+	var renditions []models.AssetRendition
+	err := r.db.Where("asset_id = ?", assetID).Order("profile").Find(&renditions).Error
+	return renditions, err
+}
+
+// ListRunOutputKeys returns the output keys of the archived task runs of an asset
+func (r *AssetRepository) ListRunOutputKeys(assetID uint) ([]string, error) {
+	var keys []string
+	err := r.db.Model(&models.TaskRun{}).
+		Joins("JOIN task_metadata t ON t.id = task_runs.task_id").
+		Where("t.asset_id = ? AND task_runs.output_s3_key <> ''", assetID).
+		Pluck("task_runs.output_s3_key", &keys).Error
+	return keys, err
+}
+
+// GetRendition retrieves the rendition of an asset for a screen profile
+func (r *AssetRepository) GetRendition(assetID uint, profile string) (*models.AssetRendition, error) {
+	var rendition models.AssetRendition
+	err := r.db.Where("asset_id = ? AND profile = ?", assetID, profile).First(&rendition).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rendition, nil
+}
+
+// ReplaceRenditions replaces all renditions of an asset in one transaction
+func (r *AssetRepository) ReplaceRenditions(assetID uint, renditions []models.AssetRendition) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("asset_id = ?", assetID).Delete(&models.AssetRendition{}).Error; err != nil {
+			return err
+		}
+		if len(renditions) == 0 {
+			return nil
+		}
+		return tx.Create(&renditions).Error
+	})
+}
+
+// UpdateFocalPoint sets or clears the focal point used to crop renditions
+func (r *AssetRepository) UpdateFocalPoint(id uint, x, y *float64) error {
+	return r.db.Model(&models.Asset{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"focal_point_x": x,
+		"focal_point_y": y,
+	}).Error
+}
+
+// ListPendingProcessing returns up to limit assets waiting for post-upload processing, oldest first
+func (r *AssetRepository) ListPendingProcessing(limit int) ([]models.Asset, error) {
+	var assets []models.Asset
+	err := r.db.Where("status = ?", models.AssetStatusPendingProcessing).Order("id").Limit(limit).Find(&assets).Error
+	return assets, err
+}
+
+// FinishProcessing marks a processed upload as uploaded unless its status changed meanwhile
+func (r *AssetRepository) FinishProcessing(id uint) error {
+	return r.db.Model(&models.Asset{}).Where("id = ? AND status = ?", id, models.AssetStatusPendingProcessing).
+		Update("status", models.AssetStatusUploaded).Error
+}
+
+// UpdatePerceptualHash records the perceptual hash of an image asset
+func (r *AssetRepository) UpdatePerceptualHash(id uint, hash string) error {
+	return r.db.Model(&models.Asset{}).Where("id = ?", id).Update("perceptual_hash", hash).Error
+}
+
+// ListPerceptualHashes returns the ID and perceptual hash of every live asset that has one
+func (r *AssetRepository) ListPerceptualHashes() ([]models.Asset, error) {
+	var assets []models.Asset
+	err := r.db.Select("id", "perceptual_hash").Where("perceptual_hash <> ''").Find(&assets).Error
+	return assets, err
+}
+
+// GetByIDs retrieves the assets with the given IDs
+func (r *AssetRepository) GetByIDs(ids []uint) ([]models.Asset, error) {
+	var assets []models.Asset
+	if len(ids) == 0 {
+		return assets, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&assets).Error
+	return assets, err
+}
+
+// UpdatePlaceholder records the BlurHash and dominant colors of an asset's image
+func (r *AssetRepository) UpdatePlaceholder(id uint, blurHash string, palette []string) error {
+	return r.db.Model(&models.Asset{ID: id}).Select("blur_hash", "palette").
+		Updates(&models.Asset{BlurHash: blurHash, Palette: palette}).Error
+}
+
+// UpdateOutputPlaceholder records the BlurHash and dominant colors of an asset's processed output
+func (r *AssetRepository) UpdateOutputPlaceholder(id uint, blurHash string, palette []string) error {
+	return r.db.Model(&models.Asset{ID: id}).Select("output_blur_hash", "output_palette").
+		Updates(&models.Asset{OutputBlurHash: blurHash, OutputPalette: palette}).Error
+}
+
+// UpdateVerdict records the moderation verdict of an asset
+func (r *AssetRepository) UpdateVerdict(id uint, verdict string, labels []string, reason string) error {
+	now := time.Now()
+	return r.db.Model(&models.Asset{ID: id}).Select("verdict", "verdict_labels", "verdict_reason", "moderated_at").
+		Updates(&models.Asset{Verdict: verdict, VerdictLabels: labels, VerdictReason: reason, ModeratedAt: &now}).Error
+}
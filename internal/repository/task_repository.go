@@ -1,11 +1,24 @@
 package repository
 
 import (
+	"time"
+
 	"screensaver-ad-backend/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// TaskFilter narrows task listings. Zero values match every task.
+type TaskFilter struct {
+	Statuses   []models.TaskStatus
+	AssetID    uint
+	TemplateID uint
+	// CreatedFrom and CreatedTo bound the creation time (inclusive from, exclusive to)
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
 // TaskRepository handles database operations for tasks
 type TaskRepository struct {
 	db *gorm.DB
@@ -21,6 +34,31 @@ func (r *TaskRepository) Create(task *models.Task) error {
 	return r.db.Create(task).Error
 }
 
+// CreateIfNotExists inserts task unless a task for the same asset and template exists,
+// in which case task is set to the existing one. It reports whether task was created.
+func (r *TaskRepository) CreateIfNotExists(task *models.Task) (bool, error) {
+	return createTaskIfNotExists(r.db, task)
+}
+
+// createTaskIfNotExists relies on the unique index on asset and template, so a task
+// inserted concurrently by another request turns into a conflict instead of a duplicate
+func createTaskIfNotExists(db *gorm.DB, task *models.Task) (bool, error) {
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(task)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	var existing models.Task
+	if err := db.Where("asset_id = ? AND template_id = ?", task.AssetID, task.TemplateID).First(&existing).Error; err != nil {
+		return false, err
+	}
+	*task = existing
+	return false, nil
+}
+
 // FindByAssetAndTemplate checks if a task exists with the given asset and template IDs
 func (r *TaskRepository) FindByAssetAndTemplate(assetID, templateID uint) (*models.Task, error) {
 	var task models.Task
@@ -44,4 +82,181 @@ func (r *TaskRepository) GetByIDWithAsset(id uint) (*models.Task, error) {
 // Update updates a task record
 func (r *TaskRepository) Update(task *models.Task) error {
 	return r.db.Save(task).Error
-}
\ No newline at end of file
+}
+
+// Transition applies updates to a task only if it is still in status from, so concurrent
+// transitions cannot overwrite each other. It reports whether the task was updated.
+func (r *TaskRepository) Transition(id uint, from models.TaskStatus, updates map[string]interface{}) (bool, error) {
+	result := r.db.Model(&models.Task{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetByIDWithRelations retrieves a task by ID with its asset, template, dispatch attempts and previous runs
+func (r *TaskRepository) GetByIDWithRelations(id uint) (*models.Task, error) {
+	var task models.Task
+	err := r.db.Preload("Asset").Preload("Template").Preload("Dispatches", func(db *gorm.DB) *gorm.DB {
+		return db.Order("run, attempt")
+	}).Preload("Runs", func(db *gorm.DB) *gorm.DB {
+		return db.Order("run")
+	}).First(&task, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// List retrieves the tasks matching filter with their asset and template, newest first,
+// together with the total number of matches
+func (r *TaskRepository) List(filter TaskFilter, limit, offset int) ([]models.Task, int64, error) {
+	var count int64
+	if err := r.db.Model(&models.Task{}).Scopes(filter.scope).Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var tasks []models.Task
+	err := r.db.Scopes(filter.scope).Preload("Asset").Preload("Template").
+		Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&tasks).Error
+	return tasks, count, err
+}
+
+func (f TaskFilter) scope(db *gorm.DB) *gorm.DB {
+	if len(f.Statuses) > 0 {
+		db = db.Where("status IN ?", f.Statuses)
+	}
+	if f.AssetID != 0 {
+		db = db.Where("asset_id = ?", f.AssetID)
+	}
+	if f.TemplateID != 0 {
+		db = db.Where("template_id = ?", f.TemplateID)
+	}
+	if f.CreatedFrom != nil {
+		db = db.Where("created_at >= ?", *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		db = db.Where("created_at < ?", *f.CreatedTo)
+	}
+	return db
+}
+
+// CreateDispatch records an attempt to send a task to the worker
+func (r *TaskRepository) CreateDispatch(dispatch *models.TaskDispatch) error {
+	return r.db.Create(dispatch).Error
+}
+
+// CountDispatches returns the number of dispatch attempts of a task run
+func (r *TaskRepository) CountDispatches(taskID uint, run int) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.TaskDispatch{}).Where("task_id = ? AND run = ?", taskID, run).Count(&count).Error
+	return count, err
+}
+
+// CountFailedDispatches returns the number of failed dispatch attempts of a task run
+func (r *TaskRepository) CountFailedDispatches(taskID uint, run int) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.TaskDispatch{}).Where("task_id = ? AND run = ? AND status = ?", taskID, run, models.DispatchStatusFailed).Count(&count).Error
+	return count, err
+}
+
+// ListUndispatched returns pending tasks whose current run was never sent to the worker
+// successfully and has fewer than maxAttempts dispatch attempts, oldest first. Only the
+// dispatches since the task was last requeued count.
+func (r *TaskRepository) ListUndispatched(maxAttempts int) ([]models.Task, error) {
+	const current = "d.task_id = task_metadata.id AND d.run = task_metadata.run AND (task_metadata.requeued_at IS NULL OR d.created_at >= task_metadata.requeued_at)"
+	var tasks []models.Task
+	err := r.db.Where("status = ?", models.TaskStatusPending).
+		Where("NOT EXISTS (SELECT 1 FROM task_dispatches d WHERE "+current+" AND d.status = ?)", models.DispatchStatusSent).
+		Where("(SELECT COUNT(*) FROM task_dispatches d WHERE "+current+") < ?", maxAttempts).
+		Order("id").Find(&tasks).Error
+	return tasks, err
+}
+
+// ListDueRetries returns the tasks whose scheduled retry is due at now
+func (r *TaskRepository) ListDueRetries(now time.Time) ([]models.Task, error) {
+	var tasks []models.Task
+	err := r.db.Where("status = ? AND next_retry_at <= ?", models.TaskStatusRetryScheduled, now).
+		Order("next_retry_at").Find(&tasks).Error
+	return tasks, err
+}
+
+// Rerun archives the current run of a task and applies updates that reset it, provided
+// the task is still in status from. It reports whether the task was reset.
+func (r *TaskRepository) Rerun(taskID uint, from models.TaskStatus, archived *models.TaskRun, updates map[string]interface{}) (bool, error) {
+	reset := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Task{}).Where("id = ? AND status = ?", taskID, from).Updates(updates)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		reset = true
+		return tx.Create(archived).Error
+	})
+	return reset, err
+}
+
+// CreateBatch stores a batch and its items in one transaction. Items with a Task are
+// resolved first: the task is created, or the item is linked to the existing task for
+// the same asset and template, including one created concurrently.
+func (r *TaskRepository) CreateBatch(batch *models.TaskBatch) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range batch.Items {
+			item := &batch.Items[i]
+			if item.Task == nil {
+				continue
+			}
+
+			created, err := createTaskIfNotExists(tx, item.Task)
+			if err != nil {
+				return err
+			}
+			item.Result = models.TaskBatchResultExisting
+			if created {
+				item.Result = models.TaskBatchResultCreated
+			}
+			item.TaskID = &item.Task.ID
+		}
+
+		batch.Total, batch.Created, batch.Existing, batch.Invalid = len(batch.Items), 0, 0, 0
+		for _, item := range batch.Items {
+			switch item.Result {
+			case models.TaskBatchResultCreated:
+				batch.Created++
+			case models.TaskBatchResultExisting:
+				batch.Existing++
+			case models.TaskBatchResultInvalid:
+				batch.Invalid++
+			}
+		}
+		if err := tx.Omit("Items").Create(batch).Error; err != nil {
+			return err
+		}
+		if len(batch.Items) == 0 {
+			return nil
+		}
+		for i := range batch.Items {
+			batch.Items[i].BatchID = batch.ID
+		}
+		return tx.CreateInBatches(&batch.Items, 500).Error
+	})
+}
+
+// GetBatch retrieves a batch with its items and the current status of their tasks
+func (r *TaskRepository) GetBatch(id uint) (*models.TaskBatch, error) {
+	var batch models.TaskBatch
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Select("task_batch_items.*, t.status AS task_status").
+			Joins("LEFT JOIN task_metadata t ON t.id = task_batch_items.task_id AND t.deleted_at IS NULL").
+			Order("task_batch_items.id")
+	}).First(&batch, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// ListInProgress returns the tasks that are waiting for or being processed by the worker
+func (r *TaskRepository) ListInProgress() ([]models.Task, error) {
+	var tasks []models.Task
+	err := r.db.Where("status IN ?", []models.TaskStatus{models.TaskStatusPending, models.TaskStatusQueued, models.TaskStatusRunning}).
+		Order("id").Find(&tasks).Error
+	return tasks, err
+}
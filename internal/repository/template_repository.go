@@ -23,3 +23,54 @@ func (r *TemplateRepository) List() ([]models.Template, error) {
 	err := r.db.Find(&templates).Error
 	return templates, err
 }
+
+// ListStorageKeys returns the keys of every template, including soft-deleted ones
+func (r *TemplateRepository) ListStorageKeys() ([]string, error) {
+	var keys []string
+	err := r.db.Unscoped().Model(&models.Template{}).Pluck("s3_key", &keys).Error
+	return keys, err
+}
+
+// Delete soft deletes a template by its ID
+func (r *TemplateRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Template{}, id).Error
+}
+
+// GetByID retrieves a template by its ID
+func (r *TemplateRepository) GetByID(id uint) (*models.Template, error) {
+	var template models.Template
+	err := r.db.First(&template, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// UpdateRequirements replaces the asset requirements of a template
+func (r *TemplateRepository) UpdateRequirements(id uint, requirements models.TemplateRequirements) error {
+	return r.db.Model(&models.Template{ID: id}).
+		Select("accepted_media_types", "aspect_ratios", "min_duration", "max_duration").
+		Updates(&models.Template{Requirements: requirements}).Error
+}
+
+// UpdateRetryPolicy replaces the retry policy of a template
+func (r *TemplateRepository) UpdateRetryPolicy(id uint, policy models.RetryPolicy) error {
+	return r.db.Model(&models.Template{ID: id}).
+		Select("retry_max_attempts", "retry_base_delay", "retry_max_delay", "retry_jitter").
+		Updates(&models.Template{RetryPolicy: policy}).Error
+}
+
+// GetByIDs retrieves the templates with the given IDs
+func (r *TemplateRepository) GetByIDs(ids []uint) ([]models.Template, error) {
+	var templates []models.Template
+	if len(ids) == 0 {
+		return templates, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&templates).Error
+	return templates, err
+}
+
+// UpdateTaskTimeout sets or clears the task timeout of a template
+func (r *TemplateRepository) UpdateTaskTimeout(id uint, timeout *float64) error {
+	return r.db.Model(&models.Template{}).Where("id = ?", id).Update("task_timeout", timeout).Error
+}
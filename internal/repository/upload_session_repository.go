@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"screensaver-ad-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UploadSessionRepository handles database operations for resumable upload sessions
+type UploadSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewUploadSessionRepository creates a new upload session repository instance
+func NewUploadSessionRepository(db *gorm.DB) *UploadSessionRepository {
+	return &UploadSessionRepository{db: db}
+}
+
+// Create inserts a new upload session into the database
+func (r *UploadSessionRepository) Create(session *models.UploadSession) error {
+	return r.db.Create(session).Error
+}
+
+// GetByID retrieves an upload session by its ID
+func (r *UploadSessionRepository) GetByID(id string) (*models.UploadSession, error) {
+	var session models.UploadSession
+	err := r.db.First(&session, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// AdvanceOffset moves the offset from `from` to `to` and records the staged chunk keys,
+// only if the offset still equals `from`. It returns false when another request
+// advanced the upload concurrently.
+func (r *UploadSessionRepository) AdvanceOffset(id string, from, to int64, chunks []string) (bool, error) {
+	result := r.db.Model(&models.UploadSession{}).
+		Where("id = ? AND upload_offset = ?", id, from).
+		Select("upload_offset", "chunks").
+		Updates(&models.UploadSession{Offset: to, Chunks: chunks})
+	return result.RowsAffected == 1, result.Error
+}
+
+// SetAsset links a completed upload session to the asset created from it
+func (r *UploadSessionRepository) SetAsset(id string, assetID uint) error {
+	return r.db.Model(&models.UploadSession{}).Where("id = ?", id).Update("asset_id", assetID).Error
+}
+
+// Fail records why assembling a completed upload session failed
+func (r *UploadSessionRepository) Fail(id, message string) error {
+	return r.db.Model(&models.UploadSession{}).Where("id = ?", id).Update("error", message).Error
+}
+
+// Delete removes an upload session
+func (r *UploadSessionRepository) Delete(id string) error {
+	return r.db.Delete(&models.UploadSession{}, "id = ?", id).Error
+}
+
+// ListExpired returns incomplete upload sessions that expired before cutoff
+func (r *UploadSessionRepository) ListExpired(cutoff time.Time) ([]models.UploadSession, error) {
+	var sessions []models.UploadSession
+	err := r.db.Where("expires_at < ? AND asset_id IS NULL", cutoff).Find(&sessions).Error
+	return sessions, err
+}
@@ -1,26 +1,47 @@
 package services
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"mime/multipart"
+	"io"
+	"log"
+	"path"
 	"time"
 
 	"screensaver-ad-backend/config"
+	"screensaver-ad-backend/internal/media"
 	"screensaver-ad-backend/internal/models"
+	"screensaver-ad-backend/internal/moderation"
 	"screensaver-ad-backend/internal/repository"
+	"screensaver-ad-backend/internal/storage"
+)
+
+var (
+	// ErrAssetNotFound is returned when the requested asset does not exist
+	ErrAssetNotFound = errors.New("asset not found")
+	// ErrInvalidVariant is returned when an unknown asset file variant is requested
+	ErrInvalidVariant = errors.New("variant must be input or output")
+	// ErrOutputNotAvailable is returned when the output of an asset is requested before it exists
+	ErrOutputNotAvailable = errors.New("asset has no output file")
+	// ErrRetentionExpired is returned when restoring an asset that is past the retention period
+	ErrRetentionExpired = errors.New("asset is past the retention period and can no longer be restored")
 )
 
 // AssetService handles business logic for assets
 type AssetService struct {
-	repo      *repository.AssetRepository
-	s3Service *S3Service
+	repo           *repository.AssetRepository
+	storageService *StorageService
+	moderator      moderation.Moderator
 }
 
-// NewAssetService creates a new asset service instance
-func NewAssetService(repo *repository.AssetRepository) *AssetService {
+// NewAssetService creates a new asset service instance. moderator may be nil to skip moderation.
+func NewAssetService(repo *repository.AssetRepository, storageService *StorageService, moderator moderation.Moderator) *AssetService {
 	return &AssetService{
-		repo:      repo,
-		s3Service: NewS3Service(),
+		repo:           repo,
+		storageService: storageService,
+		moderator:      moderator,
 	}
 }
 
@@ -30,44 +51,259 @@ func (s *AssetService) CreateAsset(asset *models.Asset) error {
 	return s.repo.Create(asset)
 }
 
-// CreateAssetWithUpload creates a new asset with file upload to S3
-func (s *AssetService) CreateAssetWithUpload(file multipart.File, fileHeader *multipart.FileHeader, name string) (*models.Asset, error) {
-	// Validate file
-	if fileHeader.Size == 0 {
-		return nil, fmt.Errorf("file is empty")
+// CreateAssetWithUpload streams a file to storage and creates its asset record
+func (s *AssetService) CreateAssetWithUpload(body io.Reader, originalName, contentType, name string) (*models.Asset, error) {
+	// Detect the real type from the file signature (images and videos only)
+	body, contentType, err := sniffContentType(body, contentType, isValidContentType)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check content type (images and videos only)
-	contentType := fileHeader.Header.Get("Content-Type")
-	if !isValidContentType(contentType) {
-		return nil, fmt.Errorf("invalid file type: only images and videos are allowed")
+	// Read image metadata and strip EXIF before the image reaches storage
+	var imageInfo *media.ImageInfo
+	var originalSHA256 string
+	if media.IsImage(contentType) {
+		data, err := readImage(body)
+		if err != nil {
+			return nil, err
+		}
+		// Banned-hash checks match the file as uploaded, not the sanitized copy
+		originalSHA256 = sha256Hex(data)
+		data, imageInfo, err = sanitizeImage(data, contentType)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
 	}
 
-	// Upload to S3
-	s3Key, err := s.s3Service.UploadFileToS3(file, fileHeader, name, "input")
+	// Upload to storage
+	uploaded, err := s.storageService.UploadFile(body, originalName, contentType, name, "input")
 	if err != nil {
+		if errors.Is(err, ErrFileTooLarge) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to upload file: %w", err)
 	}
 
-	// Create asset record with initial status as "uploaded"
+	// Validate file
+	if uploaded.Size == 0 {
+		_ = s.storageService.DeleteFile(uploaded.Key)
+		return nil, fmt.Errorf("file is empty")
+	}
+
+	// Read duration, resolution and codec of videos; corrupt containers are rejected
+	videoInfo, err := probeVideo(s.storageService, uploaded.Key, contentType)
+	if err != nil {
+		_ = s.storageService.DeleteFile(uploaded.Key)
+		return nil, err
+	}
+
+	// Return the existing asset if identical content was uploaded before
+	if existing := s.findDuplicate(uploaded.SHA256, 0); existing != nil {
+		_ = s.storageService.DeleteFile(uploaded.Key)
+		return existing, nil
+	}
+
+	// Create asset record; thumbnails, renditions and hashes are derived by processAsset
 	asset := &models.Asset{
 		FileName:    name,
-		FileSize:    fileHeader.Size,
+		FileSize:    uploaded.Size,
 		ContentType: contentType,
-		S3Key:       s3Key,
-		S3Bucket:    config.GetS3Bucket(),
-		Status:      models.AssetStatusUploaded,
+		S3Key:       uploaded.Key,
+		S3Bucket:    s.storageService.Bucket(),
+		SHA256:      uploaded.SHA256,
+		Status:      models.AssetStatusPendingProcessing,
+	}
+	asset.OriginalSHA256 = originalSHA256
+	if asset.OriginalSHA256 == "" {
+		asset.OriginalSHA256 = uploaded.SHA256
+	}
+	if imageInfo != nil {
+		applyImageInfo(asset, imageInfo)
+	}
+	if videoInfo != nil {
+		applyVideoInfo(asset, videoInfo)
 	}
 
 	if err := s.repo.Create(asset); err != nil {
-		// Rollback: delete file from S3 if database insert fails
-		_ = s.s3Service.DeleteFileFromS3(s3Key)
+		// Rollback: delete file from storage if database insert fails
+		_ = s.storageService.DeleteFile(uploaded.Key)
 		return nil, fmt.Errorf("failed to create asset record: %w", err)
 	}
 
+	s.processInline(asset)
 	return asset, nil
 }
 
+// InitiateUpload creates a pending asset and returns a presigned URL the client uploads the file to
+func (s *AssetService) InitiateUpload(name, fileName, contentType string, fileSize int64) (*models.Asset, string, error) {
+	if fileSize <= 0 {
+		return nil, "", fmt.Errorf("file_size must be greater than zero")
+	}
+	contentType = media.NormalizeContentType(contentType)
+	if !isValidContentType(contentType) {
+		return nil, "", fmt.Errorf("invalid file type: only images and videos are allowed")
+	}
+	if fileSize > config.GetUploadConfig().MaxSizeFor(contentType) {
+		return nil, "", ErrFileTooLarge
+	}
+	if name == "" {
+		name = fileName
+	}
+
+	expiry := config.GetUploadConfig().PresignExpiry
+	expiresAt := time.Now().Add(expiry)
+	asset := &models.Asset{
+		FileName:        name,
+		FileSize:        fileSize,
+		ContentType:     contentType,
+		S3Key:           generateKey("input", name, fileName),
+		S3Bucket:        s.storageService.Bucket(),
+		Status:          models.AssetStatusPending,
+		UploadExpiresAt: &expiresAt,
+	}
+
+	uploadURL, err := s.storageService.GetUploadURL(asset.S3Key, contentType, expiry)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate upload URL: %w", err)
+	}
+
+	if err := s.repo.Create(asset); err != nil {
+		return nil, "", fmt.Errorf("failed to create asset record: %w", err)
+	}
+
+	return asset, uploadURL, nil
+}
+
+// CompleteUpload verifies that a pending upload reached storage and marks the asset as uploaded
+func (s *AssetService) CompleteUpload(id uint) (*models.Asset, error) {
+	asset, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrAssetNotFound
+	}
+	if asset.Status != models.AssetStatusPending {
+		return nil, fmt.Errorf("asset upload is not pending")
+	}
+	if asset.UploadExpiresAt != nil && time.Now().After(*asset.UploadExpiresAt) {
+		return nil, fmt.Errorf("upload has expired")
+	}
+
+	info, err := s.storageService.HeadFile(asset.S3Key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, fmt.Errorf("file has not been uploaded")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify upload: %w", err)
+	}
+
+	if info.Size != asset.FileSize {
+		return nil, fmt.Errorf("uploaded size %d does not match declared size %d", info.Size, asset.FileSize)
+	}
+	if contentType := media.NormalizeContentType(info.ContentType); contentType != asset.ContentType {
+		return nil, fmt.Errorf("uploaded content type %q does not match declared type %q", info.ContentType, asset.ContentType)
+	}
+
+	// Verify the file signature; a mismatching upload cannot be fixed by retrying the PUT
+	header, err := s.storageService.ReadHeader(asset.S3Key, media.SniffLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if _, err := checkContentType(header, asset.ContentType, isValidContentType); err != nil {
+		_ = s.storageService.DeleteFile(asset.S3Key)
+		_ = s.repo.UpdateStatus(asset.ID, models.AssetStatusUploadFailed)
+		return nil, err
+	}
+
+	// Strip EXIF from directly uploaded images before the asset is published
+	if media.IsImage(asset.ContentType) {
+		if err := s.sanitizeStoredImage(asset); err != nil {
+			var contentErr *ContentError
+			if errors.As(err, &contentErr) {
+				_ = s.storageService.DeleteFile(asset.S3Key)
+				_ = s.repo.UpdateStatus(asset.ID, models.AssetStatusUploadFailed)
+				return nil, err
+			}
+			return nil, fmt.Errorf("failed to process image: %w", err)
+		}
+	}
+
+	// Probe directly uploaded videos; corrupt containers cannot be fixed by retrying
+	videoInfo, err := probeVideo(s.storageService, asset.S3Key, asset.ContentType)
+	if err != nil {
+		var contentErr *ContentError
+		if errors.As(err, &contentErr) {
+			_ = s.storageService.DeleteFile(asset.S3Key)
+			_ = s.repo.UpdateStatus(asset.ID, models.AssetStatusUploadFailed)
+		}
+		return nil, err
+	}
+	if videoInfo != nil {
+		applyVideoInfo(asset, videoInfo)
+	}
+
+	sha, err := s.storageService.HashFile(asset.S3Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash upload: %w", err)
+	}
+
+	// Drop the pending asset in favour of an existing one with identical content
+	if existing := s.findDuplicate(sha, asset.ID); existing != nil {
+		_ = s.storageService.DeleteFile(asset.S3Key)
+		if err := s.repo.HardDelete(asset.ID); err != nil {
+			return nil, fmt.Errorf("failed to remove duplicate upload: %w", err)
+		}
+		return existing, nil
+	}
+
+	asset.SHA256 = sha
+	if asset.OriginalSHA256 == "" {
+		asset.OriginalSHA256 = sha
+	}
+	asset.Status = models.AssetStatusPendingProcessing
+	asset.UploadedAt = time.Now()
+	asset.UploadExpiresAt = nil
+	if err := s.repo.Update(asset); err != nil {
+		return nil, fmt.Errorf("failed to update asset: %w", err)
+	}
+
+	s.processInline(asset)
+	return asset, nil
+}
+
+// ExpirePendingUploads removes pending uploads that were never completed, along with any uploaded file
+func (s *AssetService) ExpirePendingUploads() error {
+	assets, err := s.repo.ListExpiredPending(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, asset := range assets {
+		if err := s.storageService.DeleteFile(asset.S3Key); err != nil {
+			log.Printf("Failed to delete expired upload %s: %v", asset.S3Key, err)
+			continue
+		}
+		if err := s.repo.HardDelete(asset.ID); err != nil {
+			return err
+		}
+	}
+
+	if len(assets) > 0 {
+		log.Printf("Removed %d expired pending uploads", len(assets))
+	}
+	return nil
+}
+
+// findDuplicate returns a stored asset other than assetID (0 for a new asset) with the given
+// content hash, marked as the duplicate target
+func (s *AssetService) findDuplicate(sha256 string, assetID uint) *models.Asset {
+	existing, err := s.repo.FindBySHA256(sha256, assetID)
+	if err != nil {
+		return nil
+	}
+	existing.DuplicateOf = &existing.ID
+	return existing
+}
+
 // UpdateAssetStatus updates the status of an asset and optionally sets the output S3 key
 func (s *AssetService) UpdateAssetStatus(id uint, status models.AssetStatus, outputS3Key *string) error {
 	// Validate status
@@ -78,7 +314,7 @@ func (s *AssetService) UpdateAssetStatus(id uint, status models.AssetStatus, out
 	// Get asset
 	asset, err := s.repo.GetByID(id)
 	if err != nil {
-		return fmt.Errorf("asset not found")
+		return ErrAssetNotFound
 	}
 
 	// Update status
@@ -96,7 +332,11 @@ func (s *AssetService) UpdateAssetStatus(id uint, status models.AssetStatus, out
 	}
 
 	// Update asset
-	return s.repo.Update(asset)
+	if err := s.repo.Update(asset); err != nil {
+		return err
+	}
+	s.processOutput(asset)
+	return nil
 }
 
 // isValidContentType checks if the content type is valid (image or video)
@@ -114,9 +354,14 @@ func isValidContentType(contentType string) bool {
 	return false
 }
 
+// isValidVideoType checks if the content type is an accepted video type
+func isValidVideoType(contentType string) bool {
+	return media.IsVideo(contentType) && isValidContentType(contentType)
+}
+
 // GetAssetByID retrieves an asset by its ID
 func (s *AssetService) GetAssetByID(id uint) (*models.Asset, error) {
-	return s.repo.GetByID(id)
+	return s.repo.GetByIDWithRenditions(id)
 }
 
 // GetAllAssets retrieves all assets with pagination
@@ -127,7 +372,20 @@ func (s *AssetService) GetAllAssets(limit, offset int) ([]models.Asset, error) {
 	if limit > 100 {
 		limit = 100 // max limit
 	}
-	return s.repo.GetAll(limit, offset)
+
+	assets, err := s.repo.GetAll(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := range assets {
+		urls, err := s.thumbnailURLs(&assets[i], 60*time.Minute)
+		if err != nil {
+			log.Printf("Failed to sign thumbnail URLs for asset %d: %v", assets[i].ID, err)
+			continue
+		}
+		assets[i].ThumbnailURLs = urls
+	}
+	return assets, nil
 }
 
 // UpdateAsset updates an existing asset
@@ -141,23 +399,181 @@ func (s *AssetService) DeleteAsset(id uint) error {
 	return s.repo.Delete(id)
 }
 
+// ListTrash retrieves deleted assets that can still be restored
+func (s *AssetService) ListTrash(limit, offset int) ([]models.Asset, int64, error) {
+	if limit <= 0 {
+		limit = 10 // default limit
+	}
+	if limit > 100 {
+		limit = 100 // max limit
+	}
+
+	since := time.Now().Add(-config.GetRetentionConfig().Period)
+	assets, err := s.repo.ListDeletedSince(since, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	count, err := s.repo.CountDeletedSince(since)
+	if err != nil {
+		return nil, 0, err
+	}
+	return assets, count, nil
+}
+
+// RestoreAsset undoes the deletion of an asset that is still inside the retention window
+func (s *AssetService) RestoreAsset(id uint) (*models.Asset, error) {
+	asset, err := s.repo.GetDeletedByID(id)
+	if err != nil {
+		return nil, ErrAssetNotFound
+	}
+	if time.Since(asset.DeletedAt.Time) > config.GetRetentionConfig().Period {
+		return nil, ErrRetentionExpired
+	}
+
+	if err := s.repo.Restore(id); err != nil {
+		return nil, fmt.Errorf("failed to restore asset: %w", err)
+	}
+	return s.repo.GetByID(id)
+}
+
+// PurgeDeletedAssets permanently removes assets deleted longer than the retention
+// period ago, together with their input and output files
+func (s *AssetService) PurgeDeletedAssets() error {
+	cutoff := time.Now().Add(-config.GetRetentionConfig().Period)
+	assets, err := s.repo.ListDeletedBefore(cutoff)
+	if err != nil {
+		return err
+	}
+
+	purged := 0
+	for _, asset := range assets {
+		if err := s.deleteAssetFiles(&asset); err != nil {
+			log.Printf("Failed to delete files of asset %d: %v", asset.ID, err)
+			continue
+		}
+		if err := s.repo.HardDelete(asset.ID); err != nil {
+			return err
+		}
+		purged++
+	}
+
+	if purged > 0 {
+		log.Printf("Purged %d deleted assets", purged)
+	}
+	return nil
+}
+
+// deleteAssetFiles removes every stored file belonging to an asset
+func (s *AssetService) deleteAssetFiles(asset *models.Asset) error {
+	if err := s.storageService.DeleteFile(asset.S3Key); err != nil {
+		return err
+	}
+	if asset.OutputS3Key != nil && *asset.OutputS3Key != "" {
+		if err := s.storageService.DeleteFile(*asset.OutputS3Key); err != nil {
+			return err
+		}
+	}
+	for _, thumbnail := range asset.Thumbnails {
+		if err := s.storageService.DeleteFile(thumbnail.Key); err != nil {
+			return err
+		}
+	}
+
+	renditions, err := s.repo.ListRenditions(asset.ID)
+	if err != nil {
+		return err
+	}
+	for _, rendition := range renditions {
+		if err := s.storageService.DeleteFile(rendition.S3Key); err != nil {
+			return err
+		}
+	}
+
+	// Outputs of earlier task runs are kept as history until the asset is purged
+	runKeys, err := s.repo.ListRunOutputKeys(asset.ID)
+	if err != nil {
+		return err
+	}
+	for _, key := range runKeys {
+		if err := s.storageService.DeleteFile(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetAssetCount returns the total number of assets
 func (s *AssetService) GetAssetCount() (int64, error) {
 	return s.repo.Count()
 }
 
-// AssetURLs holds both input and output presigned URLs
+// AssetContent is an opened asset file ready to be streamed
+type AssetContent struct {
+	Reader      *storage.RangeReader
+	Info        *storage.ObjectInfo
+	FileName    string
+	ContentType string
+}
+
+// OpenAssetContent opens the input or output file of an asset for streaming
+func (s *AssetService) OpenAssetContent(ctx context.Context, id uint, variant string) (*AssetContent, error) {
+	asset, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrAssetNotFound
+	}
+
+	content := &AssetContent{}
+	var key string
+	switch variant {
+	case "", "input":
+		key = asset.S3Key
+		content.FileName = asset.FileName
+		content.ContentType = asset.ContentType
+	case "output":
+		if asset.OutputS3Key == nil || *asset.OutputS3Key == "" {
+			return nil, ErrOutputNotAvailable
+		}
+		key = *asset.OutputS3Key
+		content.FileName = path.Base(key)
+	default:
+		return nil, ErrInvalidVariant
+	}
+
+	reader, info, err := s.storageService.OpenFile(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	content.Reader = reader
+	content.Info = info
+	if content.ContentType == "" {
+		content.ContentType = info.ContentType
+	}
+	return content, nil
+}
+
+// AssetURLs holds both input and output signed download URLs
 type AssetURLs struct {
-	InputURL  string  `json:"input_url"`
-	OutputURL *string `json:"output_url,omitempty"`
+	InputURL      string            `json:"input_url"`
+	OutputURL     *string           `json:"output_url,omitempty"`
+	ThumbnailURLs map[string]string `json:"thumbnail_urls,omitempty"`
+	Rendition     *RenditionURL     `json:"rendition,omitempty"`
+}
+
+// RenditionURL is the signed URL of the rendition for a screen profile
+type RenditionURL struct {
+	Profile string `json:"profile"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	URL     string `json:"url"`
 }
 
-// GetAssetURLs generates presigned URLs for both input and output files
-func (s *AssetService) GetAssetURLs(id uint, expirationMinutes int) (*AssetURLs, error) {
+// GetAssetURLs generates signed URLs (storage presigned or CDN) for both input and output files.
+// When profile is set, the URL of the rendition for that screen profile is included.
+func (s *AssetService) GetAssetURLs(id uint, expirationMinutes int, profile string) (*AssetURLs, error) {
 	// Get asset
 	asset, err := s.repo.GetByID(id)
 	if err != nil {
-		return nil, fmt.Errorf("asset not found")
+		return nil, ErrAssetNotFound
 	}
 
 	// Default expiration to 60 minutes if not specified
@@ -167,8 +583,8 @@ func (s *AssetService) GetAssetURLs(id uint, expirationMinutes int) (*AssetURLs,
 
 	expiration := time.Duration(expirationMinutes) * time.Minute
 
-	// Generate presigned URL for input file
-	inputURL, err := s.s3Service.GetFileURL(asset.S3Key, expiration)
+	// Generate signed URL for input file
+	inputURL, err := s.storageService.GetFileURL(asset.S3Key, expiration)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate input URL: %w", err)
 	}
@@ -177,14 +593,41 @@ func (s *AssetService) GetAssetURLs(id uint, expirationMinutes int) (*AssetURLs,
 		InputURL: inputURL,
 	}
 
-	// Generate presigned URL for output file if it exists
+	// Generate signed URL for output file if it exists
 	if asset.OutputS3Key != nil && *asset.OutputS3Key != "" {
-		outputURL, err := s.s3Service.GetFileURL(*asset.OutputS3Key, expiration)
+		outputURL, err := s.storageService.GetFileURL(*asset.OutputS3Key, expiration)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate output URL: %w", err)
 		}
 		urls.OutputURL = &outputURL
 	}
 
+	// Generate signed URLs for the thumbnails of image assets
+	urls.ThumbnailURLs, err = s.thumbnailURLs(asset, expiration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate thumbnail URLs: %w", err)
+	}
+
+	// Generate signed URL for the rendition of the requested screen profile
+	if profile != "" {
+		if _, ok := config.GetMediaConfig().Profile(profile); !ok {
+			return nil, ErrUnknownProfile
+		}
+		rendition, err := s.repo.GetRendition(asset.ID, profile)
+		if err != nil {
+			return nil, ErrRenditionNotFound
+		}
+		renditionURL, err := s.storageService.GetFileURL(rendition.S3Key, expiration)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate rendition URL: %w", err)
+		}
+		urls.Rendition = &RenditionURL{
+			Profile: rendition.Profile,
+			Width:   rendition.Width,
+			Height:  rendition.Height,
+			URL:     renditionURL,
+		}
+	}
+
 	return urls, nil
 }
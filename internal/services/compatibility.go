@@ -0,0 +1,154 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"screensaver-ad-backend/internal/models"
+	"screensaver-ad-backend/internal/moderation"
+)
+
+// Compatibility rule names reported in violations
+const (
+	RuleAssetStatus = "asset_status"
+	RuleModeration  = "moderation"
+	RuleMediaType   = "media_type"
+	RuleAspectRatio = "aspect_ratio"
+	RuleMinDuration = "min_duration"
+	RuleMaxDuration = "max_duration"
+	// RuleUnknownDuration is reported for videos whose duration could not be read
+	RuleUnknownDuration = "unknown_duration"
+)
+
+// aspectRatioTolerance is the relative difference up to which an asset matches an aspect ratio
+const aspectRatioTolerance = 0.01
+
+// RuleViolation describes one requirement an asset does not meet
+type RuleViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// CompatibilityError is returned when an asset cannot be combined with a template
+type CompatibilityError struct {
+	Violations []RuleViolation
+}
+
+func (e *CompatibilityError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return "asset is not compatible with template: " + strings.Join(messages, "; ")
+}
+
+// checkCompatibility returns every rule of the template the asset violates
+func checkCompatibility(asset *models.Asset, template *models.Template) []RuleViolation {
+	violations := []RuleViolation{}
+	add := func(rule, format string, args ...interface{}) {
+		violations = append(violations, RuleViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if asset.Status != models.AssetStatusUploaded && asset.Status != models.AssetStatusProcessed {
+		add(RuleAssetStatus, "asset status is %s; only uploaded or processed assets can be used", asset.Status)
+	}
+
+	if asset.Verdict == string(moderation.Reject) {
+		add(RuleModeration, "asset was rejected by moderation")
+	}
+
+	req := template.Requirements
+	if len(req.AcceptedMediaTypes) > 0 && !matchesMediaType(asset.ContentType, req.AcceptedMediaTypes) {
+		add(RuleMediaType, "media type %s is not accepted (accepted: %s)", asset.ContentType, strings.Join(req.AcceptedMediaTypes, ", "))
+	}
+
+	if len(req.AspectRatios) > 0 {
+		if asset.Width == 0 || asset.Height == 0 {
+			add(RuleAspectRatio, "asset dimensions are unknown (accepted aspect ratios: %s)", strings.Join(req.AspectRatios, ", "))
+		} else if !matchesAspectRatio(asset.Width, asset.Height, req.AspectRatios) {
+			add(RuleAspectRatio, "aspect ratio of %dx%d is not accepted (accepted: %s)", asset.Width, asset.Height, strings.Join(req.AspectRatios, ", "))
+		}
+	}
+
+	// Duration bounds only apply to videos; images have no duration
+	if strings.HasPrefix(asset.ContentType, "video/") && (req.MinDuration != nil || req.MaxDuration != nil) {
+		switch {
+		case asset.Duration == 0:
+			add(RuleUnknownDuration, "video duration could not be read, so the template's duration bounds cannot be checked")
+		case req.MinDuration != nil && asset.Duration < *req.MinDuration:
+			add(RuleMinDuration, "duration of %gs is shorter than the minimum of %gs", asset.Duration, *req.MinDuration)
+		case req.MaxDuration != nil && asset.Duration > *req.MaxDuration:
+			add(RuleMaxDuration, "duration of %gs is longer than the maximum of %gs", asset.Duration, *req.MaxDuration)
+		}
+	}
+
+	return violations
+}
+
+// matchesMediaType checks a content type against exact types and "type/*" wildcards
+func matchesMediaType(contentType string, accepted []string) bool {
+	for _, pattern := range accepted {
+		if pattern == contentType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAspectRatio(width, height int, accepted []string) bool {
+	actual := float64(width) / float64(height)
+	for _, ratio := range accepted {
+		expected, err := parseAspectRatio(ratio)
+		if err != nil {
+			continue
+		}
+		if math.Abs(actual-expected)/expected <= aspectRatioTolerance {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAspectRatio parses a "width:height" ratio such as "16:9"
+func parseAspectRatio(ratio string) (float64, error) {
+	w, h, ok := strings.Cut(ratio, ":")
+	if !ok {
+		return 0, fmt.Errorf("aspect ratio %q must be width:height", ratio)
+	}
+	width, err := strconv.ParseFloat(strings.TrimSpace(w), 64)
+	if err != nil || width <= 0 {
+		return 0, fmt.Errorf("aspect ratio %q must be width:height", ratio)
+	}
+	height, err := strconv.ParseFloat(strings.TrimSpace(h), 64)
+	if err != nil || height <= 0 {
+		return 0, fmt.Errorf("aspect ratio %q must be width:height", ratio)
+	}
+	return width / height, nil
+}
+
+// validateRequirements checks that template requirements are well-formed
+func validateRequirements(req models.TemplateRequirements) error {
+	for _, mediaType := range req.AcceptedMediaTypes {
+		major, minor, ok := strings.Cut(mediaType, "/")
+		if !ok || major == "" || minor == "" || strings.Contains(minor, "/") {
+			return fmt.Errorf("%w: media type %q must be type/subtype or type/*", ErrInvalidRequirements, mediaType)
+		}
+	}
+	for _, ratio := range req.AspectRatios {
+		if _, err := parseAspectRatio(ratio); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidRequirements, err)
+		}
+	}
+	if req.MinDuration != nil && *req.MinDuration < 0 {
+		return fmt.Errorf("%w: min_duration must not be negative", ErrInvalidRequirements)
+	}
+	if req.MinDuration != nil && req.MaxDuration != nil && *req.MinDuration > *req.MaxDuration {
+		return fmt.Errorf("%w: min_duration must not exceed max_duration", ErrInvalidRequirements)
+	}
+	return nil
+}
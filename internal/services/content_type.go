@@ -0,0 +1,64 @@
+package services
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+
+	"screensaver-ad-backend/internal/media"
+)
+
+// Error codes returned when an uploaded file fails content validation
+const (
+	ErrCodeUnsupportedType  = "unsupported_content_type"
+	ErrCodeContentMismatch  = "content_type_mismatch"
+	ErrCodeCorruptImage     = "corrupt_image"
+	ErrCodeCorruptContainer = "corrupt_container"
+)
+
+// ContentError is returned when an uploaded file's content is rejected
+type ContentError struct {
+	Code    string
+	Message string
+}
+
+func (e *ContentError) Error() string {
+	return e.Message
+}
+
+// sniffContentType peeks at the start of body, detects the real file type from its
+// signature and checks it against the type declared by the client and the allowed types.
+// It returns a reader that still yields the whole body, and the detected type.
+func sniffContentType(body io.Reader, declared string, allowed func(string) bool) (io.Reader, string, error) {
+	reader := bufio.NewReaderSize(body, media.SniffLength)
+	header, err := reader.Peek(media.SniffLength)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	detected, err := checkContentType(header, declared, allowed)
+	if err != nil {
+		return nil, "", err
+	}
+	return reader, detected, nil
+}
+
+// checkContentType validates the leading bytes of a file against the declared type
+func checkContentType(header []byte, declared string, allowed func(string) bool) (string, error) {
+	detected := media.DetectContentType(header)
+	if detected == "" || !allowed(detected) {
+		return "", &ContentError{
+			Code:    ErrCodeUnsupportedType,
+			Message: "invalid file type: file content is not a supported format",
+		}
+	}
+
+	if !media.IsGenericContentType(declared) && media.NormalizeContentType(declared) != detected {
+		return "", &ContentError{
+			Code:    ErrCodeContentMismatch,
+			Message: fmt.Sprintf("file content is %s but was declared as %s", detected, declared),
+		}
+	}
+	return detected, nil
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"screensaver-ad-backend/config"
+	"screensaver-ad-backend/internal/media"
+	"screensaver-ad-backend/internal/models"
+)
+
+// paletteSize is the number of dominant colors recorded for an image
+const paletteSize = 5
+
+// processBatchSize is the number of uploads ProcessUploads handles per run
+const processBatchSize = 20
+
+// readImage buffers an image upload, which has to be complete in memory to be sanitized
+func readImage(body io.Reader) ([]byte, error) {
+	limit := config.GetUploadConfig().MaxImageSize
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrFileTooLarge
+	}
+	return data, nil
+}
+
+// sanitizeImage reads the metadata of an image and returns its content rotated upright
+// and without EXIF metadata. The returned info describes the sanitized image, with the
+// orientation of the original.
+func sanitizeImage(data []byte, contentType string) ([]byte, *media.ImageInfo, error) {
+	original, err := media.ProbeImage(data, contentType)
+	if err != nil {
+		return nil, nil, &ContentError{Code: ErrCodeCorruptImage, Message: fmt.Sprintf("image could not be read: %v", err)}
+	}
+
+	sanitized, err := media.SanitizeImage(data, contentType, original.Orientation, config.GetMediaConfig().ReencodeQuality)
+	if err != nil {
+		return nil, nil, &ContentError{Code: ErrCodeCorruptImage, Message: fmt.Sprintf("image could not be read: %v", err)}
+	}
+	info, err := media.ProbeImage(sanitized, contentType)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read sanitized image: %w", err)
+	}
+
+	// Formats that could not be rotated keep their orientation; report the upright size
+	if info.Orientation >= 5 {
+		info.Width, info.Height = info.Height, info.Width
+	}
+	info.Orientation = original.Orientation
+	return sanitized, info, nil
+}
+
+// sanitizeStoredImage sanitizes an image that was uploaded directly to storage and
+// replaces the stored file when it changed
+func (s *AssetService) sanitizeStoredImage(asset *models.Asset) error {
+	data, err := s.storageService.ReadFile(asset.S3Key, config.GetUploadConfig().MaxImageSize)
+	if err != nil {
+		return err
+	}
+	sanitized, info, err := sanitizeImage(data, asset.ContentType)
+	if err != nil {
+		return err
+	}
+	asset.OriginalSHA256 = sha256Hex(data)
+
+	if !bytes.Equal(sanitized, data) {
+		if err := s.storageService.Store().Put(context.Background(), asset.S3Key, bytes.NewReader(sanitized), int64(len(sanitized)), asset.ContentType); err != nil {
+			return fmt.Errorf("failed to store sanitized image: %w", err)
+		}
+		asset.FileSize = int64(len(sanitized))
+	}
+	applyImageInfo(asset, info)
+	return nil
+}
+
+func applyImageInfo(asset *models.Asset, info *media.ImageInfo) {
+	asset.Width = info.Width
+	asset.Height = info.Height
+	asset.Orientation = info.Orientation
+	asset.ColorModel = info.ColorModel
+}
+
+// processUpload runs the post-upload processing of a new asset. Failures are logged
+// and never fail the upload; the asset is simply left without the derived files.
+func (s *AssetService) processUpload(asset *models.Asset) {
+	if !media.IsDecodable(asset.ContentType) {
+		return
+	}
+	src, err := s.decodeAsset(asset)
+	if err != nil {
+		log.Printf("Failed to decode image of asset %d: %v", asset.ID, err)
+		return
+	}
+
+	hash := media.FormatHash(media.DHash(src))
+	if err := s.repo.UpdatePerceptualHash(asset.ID, hash); err != nil {
+		log.Printf("Failed to store perceptual hash of asset %d: %v", asset.ID, err)
+	} else {
+		asset.PerceptualHash = hash
+	}
+
+	blurHash, palette := placeholder(src)
+	if err := s.repo.UpdatePlaceholder(asset.ID, blurHash, palette); err != nil {
+		log.Printf("Failed to store placeholder of asset %d: %v", asset.ID, err)
+	} else {
+		asset.BlurHash, asset.Palette = blurHash, palette
+	}
+
+	if err := s.generateThumbnails(asset, src); err != nil {
+		log.Printf("Failed to generate thumbnails for asset %d: %v", asset.ID, err)
+	}
+	if err := s.generateRenditions(asset, src); err != nil {
+		log.Printf("Failed to generate renditions for asset %d: %v", asset.ID, err)
+	}
+}
+
+// ProcessUploads moderates and runs the post-upload processing of assets pending
+// processing, one at a time so that only one decoded image is held in memory
+func (s *AssetService) ProcessUploads() error {
+	assets, err := s.repo.ListPendingProcessing(processBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range assets {
+		s.processAsset(&assets[i])
+	}
+	return nil
+}
+
+// processAsset moderates and processes an asset pending processing and marks it uploaded
+func (s *AssetService) processAsset(asset *models.Asset) {
+	s.moderate(asset)
+	s.processUpload(asset)
+	if err := s.repo.FinishProcessing(asset.ID); err != nil {
+		log.Printf("Failed to finish processing of asset %d: %v", asset.ID, err)
+		return
+	}
+	asset.Status = models.AssetStatusUploaded
+}
+
+// processInline processes a new upload within the request when the background job is
+// disabled, so the asset never stays pending processing
+func (s *AssetService) processInline(asset *models.Asset) {
+	if config.GetMediaConfig().ProcessInterval <= 0 {
+		s.processAsset(asset)
+	}
+}
+
+// decodeAsset reads and decodes the stored image of an asset
+func (s *AssetService) decodeAsset(asset *models.Asset) (*image.RGBA, error) {
+	data, err := s.storageService.ReadFile(asset.S3Key, config.GetUploadConfig().MaxImageSize)
+	if err != nil {
+		return nil, err
+	}
+	img, err := media.DecodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+	return media.ToRGBA(img), nil
+}
+
+// processOutput derives the placeholder of an asset's first processed output. Outputs
+// that are not decodable images (e.g. rendered videos) are skipped. Failures are logged.
+func (s *AssetService) processOutput(asset *models.Asset) {
+	if asset.OutputS3Key == nil || *asset.OutputS3Key == "" || asset.OutputBlurHash != "" {
+		return
+	}
+	key := *asset.OutputS3Key
+
+	info, err := s.storageService.Store().Head(context.Background(), key)
+	if err != nil {
+		log.Printf("Failed to read output of asset %d: %v", asset.ID, err)
+		return
+	}
+	if !media.IsDecodable(info.ContentType) {
+		return
+	}
+	data, err := s.storageService.ReadFile(key, config.GetUploadConfig().MaxImageSize)
+	if err != nil {
+		log.Printf("Failed to read output of asset %d: %v", asset.ID, err)
+		return
+	}
+	img, err := media.DecodeImage(data)
+	if err != nil {
+		log.Printf("Failed to decode output of asset %d: %v", asset.ID, err)
+		return
+	}
+
+	blurHash, palette := placeholder(media.ToRGBA(img))
+	if err := s.repo.UpdateOutputPlaceholder(asset.ID, blurHash, palette); err != nil {
+		log.Printf("Failed to store output placeholder of asset %d: %v", asset.ID, err)
+		return
+	}
+	asset.OutputBlurHash, asset.OutputPalette = blurHash, palette
+}
+
+// ProcessOutput derives the placeholder of an asset's processed output if it has none yet
+func (s *AssetService) ProcessOutput(id uint) error {
+	asset, err := s.repo.GetByID(id)
+	if err != nil {
+		return ErrAssetNotFound
+	}
+	s.processOutput(asset)
+	return nil
+}
+
+// placeholder computes the BlurHash and dominant colors shown while an image loads.
+// Portrait images get more vertical than horizontal components.
+func placeholder(src *image.RGBA) (string, []string) {
+	xComponents, yComponents := 4, 3
+	if src.Bounds().Dy() > src.Bounds().Dx() {
+		xComponents, yComponents = 3, 4
+	}
+	return media.BlurHash(src, xComponents, yComponents), media.Palette(src, paletteSize)
+}
+
+// generateThumbnails stores a downscaled copy of an image asset for every configured
+// size under thumbs/<asset id>/ and records them on the asset
+func (s *AssetService) generateThumbnails(asset *models.Asset, src *image.RGBA) error {
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+
+	mediaConfig := config.GetMediaConfig()
+	thumbnails := []models.Thumbnail{}
+	for _, size := range mediaConfig.ThumbnailSizes {
+		w, h := media.FitWithin(width, height, size)
+		thumb := media.Resize(src, w, h)
+
+		var buf bytes.Buffer
+		contentType, ext, err := media.EncodeImage(&buf, thumb, mediaConfig.ThumbnailQuality)
+		if err != nil {
+			return fmt.Errorf("failed to encode thumbnail: %w", err)
+		}
+
+		key := fmt.Sprintf("thumbs/%d/%d%s", asset.ID, size, ext)
+		if err := s.storageService.Store().Put(context.Background(), key, &buf, int64(buf.Len()), contentType); err != nil {
+			s.deleteThumbnails(thumbnails)
+			return fmt.Errorf("failed to store thumbnail: %w", err)
+		}
+		thumbnails = append(thumbnails, models.Thumbnail{
+			Size:        size,
+			Width:       w,
+			Height:      h,
+			Key:         key,
+			ContentType: contentType,
+		})
+
+		// Larger sizes would not be upscaled and only repeat this one
+		if w == width && h == height {
+			break
+		}
+	}
+
+	if err := s.repo.UpdateThumbnails(asset.ID, thumbnails); err != nil {
+		s.deleteThumbnails(thumbnails)
+		return err
+	}
+	asset.Thumbnails = thumbnails
+	return nil
+}
+
+func (s *AssetService) deleteThumbnails(thumbnails []models.Thumbnail) {
+	for _, thumbnail := range thumbnails {
+		_ = s.storageService.DeleteFile(thumbnail.Key)
+	}
+}
+
+// thumbnailURLs signs a download URL for every thumbnail of an asset, keyed by size
+func (s *AssetService) thumbnailURLs(asset *models.Asset, expiration time.Duration) (map[string]string, error) {
+	if len(asset.Thumbnails) == 0 {
+		return nil, nil
+	}
+
+	urls := make(map[string]string, len(asset.Thumbnails))
+	for _, thumbnail := range asset.Thumbnails {
+		url, err := s.storageService.GetFileURL(thumbnail.Key, expiration)
+		if err != nil {
+			return nil, err
+		}
+		urls[strconv.Itoa(thumbnail.Size)] = url
+	}
+	return urls, nil
+}
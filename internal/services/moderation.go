@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"screensaver-ad-backend/internal/models"
+	"screensaver-ad-backend/internal/moderation"
+)
+
+// ErrInvalidVerdict is returned when a review sets an unknown verdict
+var ErrInvalidVerdict = errors.New("verdict must be approve, reject or needs_review")
+
+// moderate runs the configured moderator on a new upload and records its verdict. It runs
+// from processAsset, normally in the background so a slow moderator never blocks the
+// upload request; the asset stays pending_processing until the verdict is stored.
+// Moderator failures send the asset to review.
+func (s *AssetService) moderate(asset *models.Asset) {
+	if s.moderator == nil {
+		return
+	}
+
+	subject := moderation.Subject{
+		AssetID:     asset.ID,
+		FileName:    asset.FileName,
+		ContentType: asset.ContentType,
+		FileSize:    asset.FileSize,
+		SHA256:      asset.SHA256,
+		Width:       asset.Width,
+		Height:      asset.Height,
+		Duration:    asset.Duration,
+	}
+	subject.OriginalSHA256 = asset.OriginalSHA256
+	if url, err := s.storageService.GetFileURL(asset.S3Key, moderation.URLExpiry); err == nil {
+		subject.URL = url
+	}
+
+	verdict, err := s.moderator.Moderate(context.Background(), subject)
+	if err != nil {
+		log.Printf("Failed to moderate asset %d: %v", asset.ID, err)
+		verdict = &moderation.Verdict{Decision: moderation.NeedsReview, Reason: fmt.Sprintf("moderation failed: %v", err)}
+	}
+	if err := s.repo.UpdateVerdict(asset.ID, string(verdict.Decision), verdict.Labels, verdict.Reason); err != nil {
+		log.Printf("Failed to store moderation verdict of asset %d: %v", asset.ID, err)
+		return
+	}
+	asset.Verdict = string(verdict.Decision)
+	asset.VerdictLabels = verdict.Labels
+	asset.VerdictReason = verdict.Reason
+}
+
+// ReviewAsset overrides the moderation verdict of an asset after a manual review.
+// The labels of the automatic verdict are kept.
+func (s *AssetService) ReviewAsset(id uint, decision moderation.Decision, reason string) (*models.Asset, error) {
+	if !decision.Valid() {
+		return nil, ErrInvalidVerdict
+	}
+	asset, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrAssetNotFound
+	}
+	if err := s.repo.UpdateVerdict(id, string(decision), asset.VerdictLabels, reason); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"screensaver-ad-backend/config"
+	"screensaver-ad-backend/internal/models"
+	"screensaver-ad-backend/internal/repository"
+	"screensaver-ad-backend/internal/storage"
+)
+
+// ReconcileReport lists the discrepancies found between storage and the database
+type ReconcileReport struct {
+	DryRun bool `json:"dry_run"`
+	// OrphanedObjects are stored objects that no asset or template references
+	OrphanedObjects []string `json:"orphaned_objects"`
+	// MissingInputs are assets whose input file is not in storage
+	MissingInputs []uint `json:"missing_inputs"`
+	// MissingOutputs are assets whose output file is not in storage
+	MissingOutputs []uint `json:"missing_outputs"`
+	// MissingTemplates are templates whose video is not in storage
+	MissingTemplates []uint `json:"missing_templates"`
+	// Repaired counts the discrepancies fixed in this run
+	Repaired int `json:"repaired"`
+}
+
+// ReconcileService compares storage contents with asset and template records
+type ReconcileService struct {
+	assetRepo      *repository.AssetRepository
+	templateRepo   *repository.TemplateRepository
+	storageService *StorageService
+}
+
+// NewReconcileService creates a new reconciliation service instance
+func NewReconcileService(assetRepo *repository.AssetRepository, templateRepo *repository.TemplateRepository, storageService *StorageService) *ReconcileService {
+	return &ReconcileService{
+		assetRepo:      assetRepo,
+		templateRepo:   templateRepo,
+		storageService: storageService,
+	}
+}
+
+// Run lists the input, template, thumbnail, rendition and output prefixes and compares them with the database.
+// In dry-run mode discrepancies are only reported. Otherwise orphaned objects older than
+// the grace period are deleted, assets with a missing input are marked upload_failed and
+// missing outputs are cleared. Templates with a missing video are only reported.
+func (s *ReconcileService) Run(dryRun bool) (*ReconcileReport, error) {
+	if s.storageService.Store() == nil {
+		return nil, fmt.Errorf("storage is not initialized")
+	}
+	ctx := context.Background()
+	report := &ReconcileReport{
+		DryRun:           dryRun,
+		OrphanedObjects:  []string{},
+		MissingInputs:    []uint{},
+		MissingOutputs:   []uint{},
+		MissingTemplates: []uint{},
+	}
+
+	// Load the rows before listing storage, so a row never refers to an object that was
+	// stored after the listing
+	assets, err := s.assetRepo.ListStored()
+	if err != nil {
+		return nil, err
+	}
+	templates, err := s.templateRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect every object under the managed prefixes
+	prefixes := s.prefixes()
+	objects := map[string]storage.ObjectInfo{}
+	for _, prefix := range prefixes {
+		listed, err := s.storageService.Store().List(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range listed {
+			objects[obj.Key] = obj
+		}
+	}
+
+	// Collect every key referenced by a row, soft-deleted rows included
+	referenced := map[string]bool{}
+	assetKeys, err := s.assetRepo.ListStorageKeys()
+	if err != nil {
+		return nil, err
+	}
+	templateKeys, err := s.templateRepo.ListStorageKeys()
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range append(assetKeys, templateKeys...) {
+		referenced[key] = true
+	}
+
+	// Objects without rows
+	cutoff := time.Now().Add(-config.GetReconcileConfig().GracePeriod)
+	for key, obj := range objects {
+		if referenced[key] || obj.LastModified.After(cutoff) {
+			continue
+		}
+		report.OrphanedObjects = append(report.OrphanedObjects, key)
+		if !dryRun {
+			if err := s.storageService.DeleteFile(key); err != nil {
+				log.Printf("Reconcile: failed to delete orphaned object %s: %v", key, err)
+				continue
+			}
+			report.Repaired++
+		}
+	}
+
+	// Rows without objects. Each key is checked again right before a repair, since the
+	// row may have changed after it was loaded.
+	for _, asset := range assets {
+		if !s.exists(ctx, objects, asset.S3Key) {
+			report.MissingInputs = append(report.MissingInputs, asset.ID)
+			if !dryRun && s.missing(ctx, asset.S3Key) && s.repair(s.assetRepo.UpdateStatus(asset.ID, models.AssetStatusUploadFailed)) {
+				report.Repaired++
+			}
+		}
+		if asset.OutputS3Key != nil && *asset.OutputS3Key != "" && !s.exists(ctx, objects, *asset.OutputS3Key) {
+			report.MissingOutputs = append(report.MissingOutputs, asset.ID)
+			if !dryRun && s.missing(ctx, *asset.OutputS3Key) && s.repair(s.assetRepo.ClearOutputS3Key(asset.ID)) {
+				report.Repaired++
+			}
+		}
+	}
+
+	// Templates are only reported; deleting one would break the tasks that use it
+	for _, template := range templates {
+		if !s.exists(ctx, objects, template.S3Key) {
+			report.MissingTemplates = append(report.MissingTemplates, template.ID)
+		}
+	}
+
+	return report, nil
+}
+
+// RunScheduled runs a reconciliation from the background scheduler and logs the outcome
+func (s *ReconcileService) RunScheduled() error {
+	report, err := s.Run(!config.GetReconcileConfig().Repair)
+	if err != nil {
+		return err
+	}
+	log.Printf("Reconcile: %d orphaned objects, %d missing inputs, %d missing outputs, %d missing templates, %d repaired (dry run: %t)",
+		len(report.OrphanedObjects), len(report.MissingInputs), len(report.MissingOutputs), len(report.MissingTemplates), report.Repaired, report.DryRun)
+	return nil
+}
+
+// prefixes returns the storage prefixes managed by the reconciliation
+func (s *ReconcileService) prefixes() []string {
+	return []string{"input/", "template/", "thumbs/", "renditions/", config.GetOutputPrefix() + "/"}
+}
+
+// exists checks a key against the listed objects, falling back to a HEAD request
+// for keys outside the listed prefixes
+func (s *ReconcileService) exists(ctx context.Context, objects map[string]storage.ObjectInfo, key string) bool {
+	if _, ok := objects[key]; ok {
+		return true
+	}
+	for _, prefix := range s.prefixes() {
+		if strings.HasPrefix(key, prefix) {
+			return false
+		}
+	}
+
+	_, err := s.storageService.Store().Head(ctx, key)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		// Treat lookup errors as present so we never repair on a transient failure
+		log.Printf("Reconcile: failed to check object %s: %v", key, err)
+		return true
+	}
+	return err == nil
+}
+
+// missing confirms with a HEAD request that key is still not in storage
+func (s *ReconcileService) missing(ctx context.Context, key string) bool {
+	_, err := s.storageService.Store().Head(ctx, key)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("Reconcile: failed to check object %s: %v", key, err)
+		return false
+	}
+	return err != nil
+}
+
+func (s *ReconcileService) repair(err error) bool {
+	if err != nil {
+		log.Printf("Reconcile: repair failed: %v", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+
+	"screensaver-ad-backend/config"
+	"screensaver-ad-backend/internal/media"
+	"screensaver-ad-backend/internal/models"
+)
+
+var (
+	// ErrUnknownProfile is returned when a screen profile is not configured
+	ErrUnknownProfile = errors.New("unknown screen profile")
+	// ErrRenditionNotFound is returned when an asset has no rendition for a screen profile
+	ErrRenditionNotFound = errors.New("asset has no rendition for this profile")
+	// ErrInvalidFocalPoint is returned when a focal point is incomplete or outside the image
+	ErrInvalidFocalPoint = errors.New("focal point needs both x and y between 0 and 1")
+)
+
+// generateRenditions crops and resizes an image asset for every screen profile and
+// stores the results under renditions/<asset id>/, replacing earlier renditions.
+// Crops are centered on the focal point of the asset, or on the detected subject.
+func (s *AssetService) generateRenditions(asset *models.Asset, src *image.RGBA) error {
+	previous, err := s.repo.ListRenditions(asset.ID)
+	if err != nil {
+		return err
+	}
+
+	fx, fy := focalPoint(asset, src)
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	mediaConfig := config.GetMediaConfig()
+
+	renditions := make([]models.AssetRendition, 0, len(mediaConfig.ScreenProfiles))
+	for _, profile := range mediaConfig.ScreenProfiles {
+		rect := media.CropRect(width, height, profile.Width, profile.Height, fx, fy)
+		// Never upscale; screens scale smaller renditions themselves
+		outW, outH := profile.Width, profile.Height
+		if rect.Dx() < outW {
+			outW, outH = rect.Dx(), rect.Dy()
+		}
+		img := media.Resize(src.SubImage(rect), outW, outH)
+
+		var buf bytes.Buffer
+		contentType, ext, err := media.EncodeImage(&buf, img, mediaConfig.RenditionQuality)
+		if err != nil {
+			return fmt.Errorf("failed to encode rendition: %w", err)
+		}
+
+		key := fmt.Sprintf("renditions/%d/%s%s", asset.ID, profile.Name, ext)
+		size := int64(buf.Len())
+		if err := s.storageService.Store().Put(context.Background(), key, &buf, size, contentType); err != nil {
+			return fmt.Errorf("failed to store rendition: %w", err)
+		}
+		renditions = append(renditions, models.AssetRendition{
+			AssetID:     asset.ID,
+			Profile:     profile.Name,
+			Width:       outW,
+			Height:      outH,
+			S3Key:       key,
+			ContentType: contentType,
+			FileSize:    size,
+		})
+	}
+
+	if err := s.repo.ReplaceRenditions(asset.ID, renditions); err != nil {
+		return err
+	}
+
+	// Remove files of renditions that were not overwritten, e.g. of dropped profiles
+	current := make(map[string]bool, len(renditions))
+	for _, rendition := range renditions {
+		current[rendition.S3Key] = true
+	}
+	for _, rendition := range previous {
+		if !current[rendition.S3Key] {
+			_ = s.storageService.DeleteFile(rendition.S3Key)
+		}
+	}
+
+	asset.Renditions = renditions
+	return nil
+}
+
+// focalPoint returns the focal point set on the asset, or the detected subject
+func focalPoint(asset *models.Asset, src *image.RGBA) (float64, float64) {
+	if asset.FocalPointX != nil && asset.FocalPointY != nil {
+		return *asset.FocalPointX, *asset.FocalPointY
+	}
+	return media.SmartFocus(src)
+}
+
+// SetFocalPoint sets the point (x and y from 0 to 1) renditions of an image asset are
+// centered on and regenerates them. Clearing it with nil values falls back to the
+// detected subject.
+func (s *AssetService) SetFocalPoint(id uint, x, y *float64) (*models.Asset, error) {
+	if (x == nil) != (y == nil) || (x != nil && (*x < 0 || *x > 1 || *y < 0 || *y > 1)) {
+		return nil, ErrInvalidFocalPoint
+	}
+
+	asset, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrAssetNotFound
+	}
+	if !media.IsDecodable(asset.ContentType) {
+		return nil, fmt.Errorf("renditions are only generated for JPEG, PNG and GIF images")
+	}
+
+	if err := s.repo.UpdateFocalPoint(id, x, y); err != nil {
+		return nil, fmt.Errorf("failed to update focal point: %w", err)
+	}
+	asset.FocalPointX, asset.FocalPointY = x, y
+
+	src, err := s.decodeAsset(asset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if err := s.generateRenditions(asset, src); err != nil {
+		return nil, fmt.Errorf("failed to generate renditions: %w", err)
+	}
+	return asset, nil
+}
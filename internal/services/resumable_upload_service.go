@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"screensaver-ad-backend/config"
+	"screensaver-ad-backend/internal/media"
+	"screensaver-ad-backend/internal/models"
+	"screensaver-ad-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUploadNotFound is returned when a resumable upload does not exist or has expired
+	ErrUploadNotFound = errors.New("upload not found")
+	// ErrOffsetMismatch is returned when a chunk does not start at the current upload offset
+	ErrOffsetMismatch = errors.New("upload offset does not match")
+	// ErrChunkTooLarge is returned when a chunk would exceed the declared upload length
+	ErrChunkTooLarge = errors.New("chunk exceeds the declared upload length")
+	// ErrUploadFailed is returned for an upload whose file was rejected after the last chunk
+	ErrUploadFailed = errors.New("upload failed")
+)
+
+// ResumableUploadService implements chunked uploads that can be resumed after a
+// dropped connection. Each chunk is staged as its own object under uploads/<id>/
+// and the chunks are assembled into a regular asset once the upload is complete.
+type ResumableUploadService struct {
+	repo           *repository.UploadSessionRepository
+	assetService   *AssetService
+	storageService *StorageService
+}
+
+// NewResumableUploadService creates a new resumable upload service instance
+func NewResumableUploadService(repo *repository.UploadSessionRepository, assetService *AssetService, storageService *StorageService) *ResumableUploadService {
+	return &ResumableUploadService{
+		repo:           repo,
+		assetService:   assetService,
+		storageService: storageService,
+	}
+}
+
+// CreateUpload starts a new resumable upload of length bytes
+func (s *ResumableUploadService) CreateUpload(length int64, fileName, contentType, name string) (*models.UploadSession, error) {
+	if length <= 0 {
+		return nil, fmt.Errorf("upload length must be greater than zero")
+	}
+	contentType = media.NormalizeContentType(contentType)
+	if !isValidContentType(contentType) {
+		return nil, fmt.Errorf("invalid file type: only images and videos are allowed")
+	}
+	uploadConfig := config.GetUploadConfig()
+	if length > uploadConfig.MaxSizeFor(contentType) {
+		return nil, ErrFileTooLarge
+	}
+	if name == "" {
+		name = fileName
+	}
+
+	session := &models.UploadSession{
+		ID:          uuid.New().String(),
+		FileName:    fileName,
+		Name:        name,
+		ContentType: contentType,
+		Length:      length,
+		ExpiresAt:   time.Now().Add(uploadConfig.ResumableExpiry),
+	}
+	if err := s.repo.Create(session); err != nil {
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+	return session, nil
+}
+
+// GetUpload returns an upload session that has not expired
+func (s *ResumableUploadService) GetUpload(id string) (*models.UploadSession, error) {
+	session, err := s.repo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.AssetID == nil && time.Now().After(session.ExpiresAt) {
+		return nil, ErrUploadNotFound
+	}
+	return session, nil
+}
+
+// AppendChunk stores the chunk read from body at offset and returns the updated session.
+// If reading the body fails, the bytes received so far are kept and the offset advanced
+// before the error is returned. When the last byte has been received the chunks are
+// assembled and the asset is created.
+func (s *ResumableUploadService) AppendChunk(id string, offset int64, body io.Reader) (*models.UploadSession, error) {
+	session, err := s.GetUpload(id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Error != "" {
+		return nil, fmt.Errorf("%w: %s", ErrUploadFailed, session.Error)
+	}
+	if offset != session.Offset {
+		return nil, ErrOffsetMismatch
+	}
+
+	var interrupted error
+	if !session.IsComplete() {
+		remaining := session.Length - session.Offset
+		key := chunkKey(session.ID, offset)
+
+		// A dropped connection ends the chunk early; the bytes received so far are kept.
+		// Read one byte past the remaining length to detect oversized chunks.
+		partial := &partialReader{r: body}
+		counter := &limitedReader{r: io.LimitReader(partial, remaining+1)}
+		if err := s.storageService.Store().Put(context.Background(), key, counter, -1, "application/octet-stream"); err != nil {
+			return nil, fmt.Errorf("failed to store chunk: %w", err)
+		}
+		interrupted = partial.err
+		if counter.n > remaining {
+			_ = s.storageService.DeleteFile(key)
+			return nil, ErrChunkTooLarge
+		}
+		if counter.n == 0 {
+			_ = s.storageService.DeleteFile(key)
+			if interrupted != nil {
+				return nil, fmt.Errorf("failed to read chunk: %w", interrupted)
+			}
+			return session, nil
+		}
+
+		// Chunk keys are unique per request, so a request that loses the race only
+		// removes its own chunk
+		chunks := append(append([]string{}, session.Chunks...), key)
+		advanced, err := s.repo.AdvanceOffset(session.ID, offset, offset+counter.n, chunks)
+		if err != nil || !advanced {
+			_ = s.storageService.DeleteFile(key)
+			if err != nil {
+				return nil, err
+			}
+			return nil, ErrOffsetMismatch
+		}
+		session.Offset = offset + counter.n
+		session.Chunks = chunks
+	}
+	if interrupted != nil {
+		return nil, fmt.Errorf("chunk interrupted after upload offset %d: %w", session.Offset, interrupted)
+	}
+
+	if session.IsComplete() && session.AssetID == nil {
+		if err := s.finishUpload(session); err != nil {
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+// TerminateUpload discards an upload and its staged chunks
+func (s *ResumableUploadService) TerminateUpload(id string) error {
+	session, err := s.GetUpload(id)
+	if err != nil {
+		return err
+	}
+	if err := s.deleteChunks(session.ID); err != nil {
+		return err
+	}
+	return s.repo.Delete(session.ID)
+}
+
+// ExpireUploads removes resumable uploads that were not finished in time
+func (s *ResumableUploadService) ExpireUploads() error {
+	sessions, err := s.repo.ListExpired(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if err := s.deleteChunks(session.ID); err != nil {
+			log.Printf("Failed to delete chunks of expired upload %s: %v", session.ID, err)
+			continue
+		}
+		if err := s.repo.Delete(session.ID); err != nil {
+			return err
+		}
+	}
+
+	if len(sessions) > 0 {
+		log.Printf("Removed %d expired resumable uploads", len(sessions))
+	}
+	return nil
+}
+
+// finishUpload assembles the staged chunks into a new asset. When the asset cannot be
+// created the session is marked failed and its chunks are removed, since assembling
+// the same bytes again would fail the same way.
+func (s *ResumableUploadService) finishUpload(session *models.UploadSession) error {
+	reader := &chunkReader{storageService: s.storageService, keys: session.Chunks}
+	defer reader.Close()
+
+	asset, err := s.assetService.CreateAssetWithUpload(reader, session.FileName, session.ContentType, session.Name)
+	if err != nil {
+		if failErr := s.repo.Fail(session.ID, err.Error()); failErr != nil {
+			log.Printf("Failed to mark upload %s as failed: %v", session.ID, failErr)
+		}
+		session.Error = err.Error()
+		if err := s.deleteChunks(session.ID); err != nil {
+			log.Printf("Failed to delete chunks of upload %s: %v", session.ID, err)
+		}
+		return err
+	}
+
+	if err := s.repo.SetAsset(session.ID, asset.ID); err != nil {
+		return err
+	}
+	session.AssetID = &asset.ID
+
+	if err := s.deleteChunks(session.ID); err != nil {
+		log.Printf("Failed to delete chunks of upload %s: %v", session.ID, err)
+	}
+	return nil
+}
+
+func (s *ResumableUploadService) deleteChunks(id string) error {
+	chunks, err := s.storageService.Store().List(context.Background(), chunkPrefix(id))
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		if err := s.storageService.DeleteFile(chunk.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func chunkPrefix(id string) string {
+	return fmt.Sprintf("uploads/%s/", id)
+}
+
+// chunkKey returns a unique key for a chunk starting at offset, so concurrent requests
+// for the same offset never share an object
+func chunkKey(id string, offset int64) string {
+	return fmt.Sprintf("%s%020d-%s", chunkPrefix(id), offset, uuid.New().String()[:8])
+}
+
+// partialReader turns a read error into the end of the stream and records it, so the
+// bytes read before a client disconnect can still be stored
+type partialReader struct {
+	r   io.Reader
+	err error
+}
+
+func (p *partialReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if err != nil && err != io.EOF {
+		p.err = err
+		return n, io.EOF
+	}
+	return n, err
+}
+
+// chunkReader reads staged chunks one after another, opening each only when needed
+type chunkReader struct {
+	storageService *StorageService
+	keys           []string
+	current        io.ReadCloser
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.keys) == 0 {
+				return 0, io.EOF
+			}
+			body, _, err := r.storageService.Store().Get(context.Background(), r.keys[0])
+			if err != nil {
+				return 0, err
+			}
+			r.current = body
+			r.keys = r.keys[1:]
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *chunkReader) Close() error {
+	if r.current != nil {
+		return r.current.Close()
+	}
+	return nil
+}
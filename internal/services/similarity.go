@@ -0,0 +1,74 @@
+package services
+
+import (
+	"errors"
+	"sort"
+
+	"screensaver-ad-backend/internal/media"
+	"screensaver-ad-backend/internal/models"
+)
+
+// ErrNoPerceptualHash is returned when similar assets are requested for an asset without a perceptual hash
+var ErrNoPerceptualHash = errors.New("asset has no perceptual hash; only JPEG, PNG and GIF images are hashed")
+
+// DefaultSimilarityThreshold is the Hamming distance up to which images count as similar
+const DefaultSimilarityThreshold = 10
+
+// SimilarAsset is an asset whose perceptual hash is close to another asset's
+type SimilarAsset struct {
+	Asset models.Asset `json:"asset"`
+	// Distance is the Hamming distance of the perceptual hashes (0 = visually identical)
+	Distance int `json:"distance"`
+}
+
+// FindSimilarAssets returns the assets whose perceptual hash is within threshold bits
+// of the asset's hash, closest first
+func (s *AssetService) FindSimilarAssets(id uint, threshold int) ([]SimilarAsset, error) {
+	asset, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrAssetNotFound
+	}
+	if asset.PerceptualHash == "" {
+		return nil, ErrNoPerceptualHash
+	}
+	hash, err := media.ParseHash(asset.PerceptualHash)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.repo.ListPerceptualHashes()
+	if err != nil {
+		return nil, err
+	}
+	distances := map[uint]int{}
+	ids := []uint{}
+	for _, candidate := range candidates {
+		if candidate.ID == asset.ID {
+			continue
+		}
+		other, err := media.ParseHash(candidate.PerceptualHash)
+		if err != nil {
+			continue
+		}
+		if distance := media.HammingDistance(hash, other); distance <= threshold {
+			distances[candidate.ID] = distance
+			ids = append(ids, candidate.ID)
+		}
+	}
+
+	assets, err := s.repo.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	similar := make([]SimilarAsset, 0, len(assets))
+	for _, a := range assets {
+		similar = append(similar, SimilarAsset{Asset: a, Distance: distances[a.ID]})
+	}
+	sort.Slice(similar, func(i, j int) bool {
+		if similar[i].Distance != similar[j].Distance {
+			return similar[i].Distance < similar[j].Distance
+		}
+		return similar[i].Asset.ID < similar[j].Asset.ID
+	})
+	return similar, nil
+}
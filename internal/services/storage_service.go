@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"screensaver-ad-backend/config"
+	"screensaver-ad-backend/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// ErrFileTooLarge is returned when an upload exceeds the maximum size for its content type
+var ErrFileTooLarge = errors.New("file exceeds the maximum allowed size")
+
+// UploadedFile describes a file stored by UploadFile
+type UploadedFile struct {
+	Key    string
+	Size   int64
+	SHA256 string
+}
+
+// StorageService handles file operations on the configured storage backend
+type StorageService struct {
+	store  storage.Storage
+	signer storage.URLSigner
+}
+
+// NewStorageService creates a new storage service instance. Download URLs are
+// signed by signer, or presigned by the backend when signer is nil.
+func NewStorageService(store storage.Storage, signer storage.URLSigner) *StorageService {
+	if signer == nil && store != nil {
+		signer = storage.NewPresignSigner(store)
+	}
+	return &StorageService{store: store, signer: signer}
+}
+
+// Store returns the underlying storage backend
+func (s *StorageService) Store() storage.Storage {
+	return s.store
+}
+
+// Bucket returns the bucket name recorded on stored models
+func (s *StorageService) Bucket() string {
+	if s.store == nil {
+		return ""
+	}
+	return s.store.Bucket()
+}
+
+// UploadFile streams body to storage without buffering it and returns the stored key and size.
+// Uploads larger than the configured maximum for contentType fail with ErrFileTooLarge.
+func (s *StorageService) UploadFile(body io.Reader, originalName, contentType, customName, folder string) (*UploadedFile, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("storage is not initialized")
+	}
+
+	key := generateKey(folder, customName, originalName)
+	hash := sha256.New()
+	reader := &limitedReader{r: io.TeeReader(body, hash), limit: config.GetUploadConfig().MaxSizeFor(contentType)}
+	if err := s.store.Put(context.Background(), key, reader, -1, contentType); err != nil {
+		if reader.exceeded {
+			_ = s.store.Delete(context.Background(), key)
+			return nil, ErrFileTooLarge
+		}
+		return nil, err
+	}
+
+	return &UploadedFile{Key: key, Size: reader.n, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// ReadHeader returns up to n leading bytes of a stored file
+func (s *StorageService) ReadHeader(key string, n int) ([]byte, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("storage is not initialized")
+	}
+
+	body, _, err := s.store.Get(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	header, err := io.ReadAll(io.LimitReader(body, int64(n)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return header, nil
+}
+
+// ReadFile reads a whole stored file into memory, failing when it is larger than limit bytes
+func (s *StorageService) ReadFile(key string, limit int64) ([]byte, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("storage is not initialized")
+	}
+
+	body, _, err := s.store.Get(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrFileTooLarge
+	}
+	return data, nil
+}
+
+// HashFile computes the SHA-256 of a stored file
+func (s *StorageService) HashFile(key string) (string, error) {
+	if s.store == nil {
+		return "", fmt.Errorf("storage is not initialized")
+	}
+
+	body, _, err := s.store.Get(context.Background(), key)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, body); err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// sha256Hex returns the hex-encoded SHA-256 of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// DeleteFile deletes a file from storage
+func (s *StorageService) DeleteFile(key string) error {
+	if s.store == nil {
+		return fmt.Errorf("storage is not initialized")
+	}
+	return s.store.Delete(context.Background(), key)
+}
+
+// GetFileURL generates a signed URL for accessing the file through the configured signer
+func (s *StorageService) GetFileURL(key string, expiration time.Duration) (string, error) {
+	if s.signer == nil {
+		return "", fmt.Errorf("storage is not initialized")
+	}
+	return s.signer.SignURL(context.Background(), key, expiration)
+}
+
+// GetUploadURL generates a presigned URL for uploading a file directly to storage
+func (s *StorageService) GetUploadURL(key string, contentType string, expiration time.Duration) (string, error) {
+	if s.store == nil {
+		return "", fmt.Errorf("storage is not initialized")
+	}
+	return s.store.PresignPut(context.Background(), key, contentType, expiration)
+}
+
+// HeadFile returns the metadata of a stored file
+func (s *StorageService) HeadFile(key string) (*storage.ObjectInfo, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("storage is not initialized")
+	}
+	return s.store.Head(context.Background(), key)
+}
+
+// OpenFile opens a stored file for seekable reading with ranged requests
+func (s *StorageService) OpenFile(ctx context.Context, key string) (*storage.RangeReader, *storage.ObjectInfo, error) {
+	if s.store == nil {
+		return nil, nil, fmt.Errorf("storage is not initialized")
+	}
+	info, err := s.store.Head(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return storage.NewRangeReader(ctx, s.store, info), info, nil
+}
+
+// generateKey builds a unique object key inside folder, keeping the extension of originalName
+func generateKey(folder, customName, originalName string) string {
+	ext := filepath.Ext(originalName)
+	var fileName string
+	if customName != "" {
+		// Sanitize custom name
+		sanitized := strings.NewReplacer(" ", "_", "/", "_", "\\", "_").Replace(customName)
+		sanitized = strings.ToLower(sanitized)
+		fileName = fmt.Sprintf("%s_%s%s", sanitized, uuid.New().String()[:8], ext)
+	} else {
+		fileName = fmt.Sprintf("%s_%s%s", time.Now().Format("20060102_150405"), uuid.New().String()[:8], ext)
+	}
+
+	return fmt.Sprintf("%s/%s", folder, fileName)
+}
+
+// limitedReader counts bytes read and fails once more than limit bytes are read
+type limitedReader struct {
+	r        io.Reader
+	limit    int64
+	n        int64
+	exceeded bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	if l.limit > 0 && l.n > l.limit {
+		l.exceeded = true
+		return n, ErrFileTooLarge
+	}
+	return n, err
+}
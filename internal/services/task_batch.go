@@ -0,0 +1,170 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"screensaver-ad-backend/internal/models"
+)
+
+// MaxTaskBatchSize is the maximum number of asset and template pairs in one batch
+const MaxTaskBatchSize = 1000
+
+var (
+	// ErrInvalidBatch is returned for batch requests that cannot be paired up
+	ErrInvalidBatch = errors.New("invalid task batch")
+	// ErrBatchNotFound is returned when the requested batch does not exist
+	ErrBatchNotFound = errors.New("task batch not found")
+)
+
+// TaskBatchRequest lists the asset and template pairs of a batch. Without Cartesian the
+// lists are paired by position and must have the same length; with Cartesian every asset
+// is paired with every template.
+type TaskBatchRequest struct {
+	AssetIDs    []uint
+	TemplateIDs []uint
+	Cartesian   bool
+	Metadata    map[string]interface{}
+}
+
+// TaskBatchProgress is a batch with the status of its tasks
+type TaskBatchProgress struct {
+	*models.TaskBatch
+	// Statuses counts the batch's tasks by status; invalid items have no task
+	Statuses map[models.TaskStatus]int `json:"statuses"`
+	// Completed reports whether every task of the batch has finished
+	Completed bool `json:"completed"`
+}
+
+// CreateTaskBatch creates the tasks of every valid pair in one transaction and dispatches
+// the new ones. Each pair is reported as created, existing (a task for the pair already
+// exists) or invalid (unknown asset or template, or the asset does not meet the template
+// requirements). Repeated pairs are only processed once.
+func (s *TaskService) CreateTaskBatch(request TaskBatchRequest) (*models.TaskBatch, error) {
+	pairs, err := batchPairs(request)
+	if err != nil {
+		return nil, err
+	}
+
+	assets, err := s.assetRepo.GetByIDs(request.AssetIDs)
+	if err != nil {
+		return nil, err
+	}
+	assetsByID := make(map[uint]*models.Asset, len(assets))
+	for i := range assets {
+		assetsByID[assets[i].ID] = &assets[i]
+	}
+	templates, err := s.templateRepo.GetByIDs(request.TemplateIDs)
+	if err != nil {
+		return nil, err
+	}
+	templatesByID := make(map[uint]*models.Template, len(templates))
+	for i := range templates {
+		templatesByID[templates[i].ID] = &templates[i]
+	}
+
+	batch := &models.TaskBatch{Cartesian: request.Cartesian}
+	for _, pair := range pairs {
+		item := models.TaskBatchItem{AssetID: pair[0], TemplateID: pair[1]}
+		asset, template := assetsByID[pair[0]], templatesByID[pair[1]]
+		switch {
+		case asset == nil:
+			item.Result, item.Error = models.TaskBatchResultInvalid, ErrAssetNotFound.Error()
+		case template == nil:
+			item.Result, item.Error = models.TaskBatchResultInvalid, ErrTemplateNotFound.Error()
+		default:
+			if violations := checkCompatibility(asset, template); len(violations) > 0 {
+				item.Result, item.Error = models.TaskBatchResultInvalid, "asset is not compatible with template"
+				for _, violation := range violations {
+					item.Violations = append(item.Violations, violation.Message)
+				}
+				break
+			}
+			item.Task = &models.Task{
+				AssetID:    asset.ID,
+				TemplateID: template.ID,
+				Metadata:   request.Metadata,
+				Status:     models.TaskStatusPending,
+			}
+		}
+		batch.Items = append(batch.Items, item)
+	}
+
+	if err := s.repo.CreateBatch(batch); err != nil {
+		return nil, err
+	}
+
+	for _, item := range batch.Items {
+		if item.Result != models.TaskBatchResultCreated {
+			continue
+		}
+		if err := s.dispatchTask(item.Task, assetsByID[item.AssetID], templatesByID[item.TemplateID]); err != nil {
+			log.Printf("%v", err)
+		}
+	}
+	return batch, nil
+}
+
+// GetTaskBatch returns a batch with its items and the progress of its tasks
+func (s *TaskService) GetTaskBatch(id uint) (*TaskBatchProgress, error) {
+	batch, err := s.repo.GetBatch(id)
+	if err != nil {
+		return nil, ErrBatchNotFound
+	}
+
+	progress := &TaskBatchProgress{
+		TaskBatch: batch,
+		Statuses:  map[models.TaskStatus]int{},
+		Completed: true,
+	}
+	for _, item := range batch.Items {
+		if item.TaskStatus == "" {
+			continue
+		}
+		progress.Statuses[item.TaskStatus]++
+		if !item.TaskStatus.Terminal() {
+			progress.Completed = false
+		}
+	}
+	return progress, nil
+}
+
+// batchPairs expands a batch request into unique asset and template ID pairs
+func batchPairs(request TaskBatchRequest) ([][2]uint, error) {
+	if len(request.AssetIDs) == 0 || len(request.TemplateIDs) == 0 {
+		return nil, fmt.Errorf("%w: asset_ids and template_ids must not be empty", ErrInvalidBatch)
+	}
+
+	var pairs [][2]uint
+	if request.Cartesian {
+		if len(request.AssetIDs)*len(request.TemplateIDs) > MaxTaskBatchSize {
+			return nil, fmt.Errorf("%w: at most %d pairs per batch", ErrInvalidBatch, MaxTaskBatchSize)
+		}
+		for _, assetID := range request.AssetIDs {
+			for _, templateID := range request.TemplateIDs {
+				pairs = append(pairs, [2]uint{assetID, templateID})
+			}
+		}
+	} else {
+		if len(request.AssetIDs) != len(request.TemplateIDs) {
+			return nil, fmt.Errorf("%w: asset_ids and template_ids must have the same length unless cartesian is set", ErrInvalidBatch)
+		}
+		if len(request.AssetIDs) > MaxTaskBatchSize {
+			return nil, fmt.Errorf("%w: at most %d pairs per batch", ErrInvalidBatch, MaxTaskBatchSize)
+		}
+		for i := range request.AssetIDs {
+			pairs = append(pairs, [2]uint{request.AssetIDs[i], request.TemplateIDs[i]})
+		}
+	}
+
+	seen := make(map[[2]uint]bool, len(pairs))
+	unique := pairs[:0]
+	for _, pair := range pairs {
+		if !seen[pair] {
+			seen[pair] = true
+			unique = append(unique, pair)
+		}
+	}
+	return unique, nil
+}
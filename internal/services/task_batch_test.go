@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+
+	"screensaver-ad-backend/internal/models"
+)
+
+func TestTaskBatchReportsExistingTasks(t *testing.T) {
+	env := newTaskTestEnv(t)
+	existing := env.createTask(t)
+
+	other := &models.Asset{FileName: "other.png", FileSize: 1, ContentType: "image/png", S3Key: "input/other.png", S3Bucket: "local", Status: models.AssetStatusUploaded}
+	if err := env.db.Create(other).Error; err != nil {
+		t.Fatalf("create asset: %v", err)
+	}
+
+	batch, err := env.service.CreateTaskBatch(TaskBatchRequest{
+		AssetIDs:    []uint{env.asset.ID, other.ID, 999},
+		TemplateIDs: []uint{env.template.ID},
+		Cartesian:   true,
+	})
+	if err != nil {
+		t.Fatalf("CreateTaskBatch: %v", err)
+	}
+	if batch.Created != 1 || batch.Existing != 1 || batch.Invalid != 1 {
+		t.Errorf("batch = created %d, existing %d, invalid %d", batch.Created, batch.Existing, batch.Invalid)
+	}
+	if item := batch.Items[0]; item.Result != models.TaskBatchResultExisting || item.TaskID == nil || *item.TaskID != existing.ID {
+		t.Errorf("first item = %+v, want existing task %d", item, existing.ID)
+	}
+	if len(env.transport.Jobs()) != 2 {
+		t.Errorf("sent %d jobs, want 2", len(env.transport.Jobs()))
+	}
+}
+
+func TestDuplicateTaskIsRejected(t *testing.T) {
+	env := newTaskTestEnv(t)
+	task := env.createTask(t)
+
+	// A concurrent insert that missed the existing task hits the unique index
+	duplicate := &models.Task{AssetID: env.asset.ID, TemplateID: env.template.ID, Status: models.TaskStatusPending}
+	if err := env.db.Create(duplicate).Error; err == nil {
+		t.Fatalf("created a second task for the same asset and template")
+	}
+
+	// Deleted tasks do not count
+	if err := env.db.Delete(task).Error; err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+	env.createTask(t)
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"time"
+
+	"screensaver-ad-backend/config"
+	"screensaver-ad-backend/internal/dispatch"
+	"screensaver-ad-backend/internal/models"
+)
+
+// dispatchTask sends a task to the rendering worker and records the attempt. A task the
+// worker accepted is queued; after a failed send it stays pending for DispatchPendingTasks.
+func (s *TaskService) dispatchTask(task *models.Task, asset *models.Asset, template *models.Template) error {
+	if s.transport == nil {
+		return nil
+	}
+
+	attempts, err := s.repo.CountDispatches(task.ID, task.Run)
+	if err != nil {
+		return err
+	}
+	record := &models.TaskDispatch{
+		TaskID:    task.ID,
+		Run:       task.Run,
+		Attempt:   int(attempts) + 1,
+		Transport: s.transport.Name(),
+		Status:    models.DispatchStatusSent,
+		OutputKey: outputKey(task, template),
+	}
+
+	job, err := s.buildJob(task, asset, template, record.OutputKey)
+	if err == nil {
+		err = s.transport.Send(context.Background(), *job)
+	}
+	if err != nil {
+		record.Status = models.DispatchStatusFailed
+		record.Error = err.Error()
+	}
+	if recordErr := s.repo.CreateDispatch(record); recordErr != nil {
+		log.Printf("Failed to record dispatch of task %d: %v", task.ID, recordErr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to dispatch task %d: %w", task.ID, err)
+	}
+
+	// The worker may already have reported progress; its events take precedence
+	if err := s.transition(task, models.TaskStatusQueued, ""); err != nil {
+		log.Printf("Task %d dispatched but not queued: %v", task.ID, err)
+	}
+	return nil
+}
+
+// buildJob assembles the worker message with signed URLs for the asset and template files
+func (s *TaskService) buildJob(task *models.Task, asset *models.Asset, template *models.Template, outputKey string) (*dispatch.Job, error) {
+	dispatchConfig := config.GetDispatchConfig()
+
+	inputURL, err := s.storageService.GetFileURL(asset.S3Key, dispatchConfig.URLExpiry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign input URL: %w", err)
+	}
+	templateURL, err := s.storageService.GetFileURL(template.S3Key, dispatchConfig.URLExpiry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign template URL: %w", err)
+	}
+
+	return &dispatch.Job{
+		TaskID:           task.ID,
+		Run:              task.Run,
+		AssetID:          asset.ID,
+		TemplateID:       template.ID,
+		InputURL:         inputURL,
+		InputContentType: asset.ContentType,
+		TemplateURL:      templateURL,
+		ExpiresAt:        time.Now().Add(dispatchConfig.URLExpiry),
+		OutputKey:        outputKey,
+		CallbackURL:      dispatchConfig.CallbackURL,
+		Metadata:         task.Metadata,
+	}, nil
+}
+
+// outputKey is the storage key the worker renders a task run to, e.g. output/42/task-7.mp4
+// for the first run and output/42/task-7-run-2.mp4 for a re-run, so earlier outputs are kept
+func outputKey(task *models.Task, template *models.Template) string {
+	if task.Run > 1 {
+		return fmt.Sprintf("%s/%d/task-%d-run-%d%s", config.GetOutputPrefix(), task.AssetID, task.ID, task.Run, path.Ext(template.S3Key))
+	}
+	return fmt.Sprintf("%s/%d/task-%d%s", config.GetOutputPrefix(), task.AssetID, task.ID, path.Ext(template.S3Key))
+}
+
+// DispatchPendingTasks resends pending tasks whose dispatch failed, or that were created
+// while dispatch was disabled, until DISPATCH_MAX_ATTEMPTS is reached
+func (s *TaskService) DispatchPendingTasks() error {
+	if s.transport == nil {
+		return nil
+	}
+
+	tasks, err := s.repo.ListUndispatched(config.GetDispatchConfig().MaxAttempts)
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		task := &tasks[i]
+		asset, err := s.assetRepo.GetByID(task.AssetID)
+		if err != nil {
+			log.Printf("Cannot dispatch task %d: asset %d not found", task.ID, task.AssetID)
+			continue
+		}
+		template, err := s.templateRepo.GetByID(task.TemplateID)
+		if err != nil {
+			log.Printf("Cannot dispatch task %d: template %d not found", task.ID, task.TemplateID)
+			continue
+		}
+		if err := s.dispatchTask(task, asset, template); err != nil {
+			log.Printf("%v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"screensaver-ad-backend/internal/models"
+)
+
+func TestCreateTaskDispatchesJob(t *testing.T) {
+	env := newTaskTestEnv(t)
+	task := env.createTask(t)
+
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusQueued)
+
+	jobs := env.transport.Jobs()
+	if len(jobs) != 1 {
+		t.Fatalf("sent %d jobs, want 1", len(jobs))
+	}
+	job := jobs[0]
+	wantKey := fmt.Sprintf("output/%d/task-%d.mp4", env.asset.ID, task.ID)
+	if job.TaskID != task.ID || job.Run != 1 || job.AssetID != env.asset.ID || job.TemplateID != env.template.ID {
+		t.Errorf("job = %+v", job)
+	}
+	if job.OutputKey != wantKey || job.InputURL == "" || job.TemplateURL == "" || job.InputContentType != "image/png" {
+		t.Errorf("job = %+v", job)
+	}
+
+	records := env.dispatches(t, task.ID)
+	if len(records) != 1 || records[0].Status != models.DispatchStatusSent || records[0].Transport != "memory" || records[0].OutputKey != wantKey {
+		t.Errorf("dispatch records = %+v", records)
+	}
+}
+
+func TestCreateTaskReturnsExistingTask(t *testing.T) {
+	env := newTaskTestEnv(t)
+	task := env.createTask(t)
+
+	again := &models.Task{AssetID: env.asset.ID, TemplateID: env.template.ID}
+	created, err := env.service.CreateTaskIfNotExists(again)
+	if err != nil {
+		t.Fatalf("CreateTaskIfNotExists: %v", err)
+	}
+	if created || again.ID != task.ID {
+		t.Errorf("created = %t, id = %d, want existing task %d", created, again.ID, task.ID)
+	}
+	if len(env.transport.Jobs()) != 1 {
+		t.Errorf("existing task was dispatched again")
+	}
+}
+
+func TestFailedDispatchIsResent(t *testing.T) {
+	env := newTaskTestEnv(t)
+	env.transport.FailWith(errors.New("worker unavailable"))
+	task := env.createTask(t)
+
+	// The creation succeeds and the task waits for the resend job
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusPending)
+	records := env.dispatches(t, task.ID)
+	if len(records) != 1 || records[0].Status != models.DispatchStatusFailed || records[0].Error != "worker unavailable" {
+		t.Fatalf("dispatch records = %+v", records)
+	}
+
+	env.transport.FailWith(nil)
+	if err := env.service.DispatchPendingTasks(); err != nil {
+		t.Fatalf("DispatchPendingTasks: %v", err)
+	}
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusQueued)
+	records = env.dispatches(t, task.ID)
+	if len(records) != 2 || records[1].Status != models.DispatchStatusSent || records[1].Attempt != 2 {
+		t.Errorf("dispatch records = %+v", records)
+	}
+
+	// A task that was sent is not sent again
+	if err := env.service.DispatchPendingTasks(); err != nil {
+		t.Fatalf("DispatchPendingTasks: %v", err)
+	}
+	if len(env.transport.Jobs()) != 1 {
+		t.Errorf("sent %d jobs, want 1", len(env.transport.Jobs()))
+	}
+}
+
+func TestDispatchStopsAtMaxAttempts(t *testing.T) {
+	env := newTaskTestEnv(t)
+	env.transport.FailWith(errors.New("worker unavailable"))
+	task := env.createTask(t)
+
+	for i := 0; i < 5; i++ {
+		if err := env.service.DispatchPendingTasks(); err != nil {
+			t.Fatalf("DispatchPendingTasks: %v", err)
+		}
+	}
+	if records := env.dispatches(t, task.ID); len(records) != 3 {
+		t.Errorf("%d dispatch attempts, want DISPATCH_MAX_ATTEMPTS (3)", len(records))
+	}
+}
+
+func TestPendingTasksDispatchedOnceTransportIsAvailable(t *testing.T) {
+	env := newTaskTestEnv(t)
+	transport := env.service.transport
+	env.service.transport = nil
+	task := env.createTask(t)
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusPending)
+
+	env.service.transport = transport
+	if err := env.service.DispatchPendingTasks(); err != nil {
+		t.Fatalf("DispatchPendingTasks: %v", err)
+	}
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusQueued)
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"screensaver-ad-backend/internal/models"
+)
+
+// CancelTask cancels a task that has not finished. Later worker events for it are ignored.
+func (s *TaskService) CancelTask(id uint) (*models.Task, error) {
+	task, err := s.repo.GetByIDWithAsset(id)
+	if err != nil {
+		return nil, ErrTaskNotFound
+	}
+	if err := s.transition(task, models.TaskStatusCancelled, ""); err != nil {
+		return nil, err
+	}
+	return s.GetTask(id)
+}
+
+// RerunTask starts a new run of a finished task, e.g. after its template was fixed.
+// The finished run is archived with its output and metadata, and the task is reset to
+// pending and dispatched again. The asset must still meet the template requirements.
+func (s *TaskService) RerunTask(id uint) (*models.Task, error) {
+	task, err := s.repo.GetByIDWithAsset(id)
+	if err != nil {
+		return nil, ErrTaskNotFound
+	}
+	if !task.Status.Terminal() {
+		return nil, fmt.Errorf("%w: task %d is %s; cancel it before re-running", ErrInvalidTransition, task.ID, task.Status)
+	}
+
+	asset, err := s.assetRepo.GetByID(task.AssetID)
+	if err != nil {
+		return nil, ErrAssetNotFound
+	}
+	template, err := s.templateRepo.GetByID(task.TemplateID)
+	if err != nil {
+		return nil, ErrTemplateNotFound
+	}
+	if violations := checkCompatibility(asset, template); len(violations) > 0 {
+		return nil, &CompatibilityError{Violations: violations}
+	}
+
+	archived := &models.TaskRun{
+		TaskID:     task.ID,
+		Run:        task.Run,
+		Status:     task.Status,
+		Attempts:   task.Attempts,
+		Error:      task.Error,
+		Metadata:   task.Metadata,
+		QueuedAt:   task.QueuedAt,
+		StartedAt:  task.StartedAt,
+		FinishedAt: finishedAt(task),
+	}
+	if key, ok := task.Metadata["s3_key"].(string); ok {
+		archived.OutputS3Key = key
+	}
+
+	reset, err := s.repo.Rerun(task.ID, task.Status, archived, map[string]interface{}{
+		"status":        models.TaskStatusPending,
+		"run":           task.Run + 1,
+		"attempts":      0,
+		"error":         "",
+		"metadata":      nil,
+		"queued_at":     nil,
+		"started_at":    nil,
+		"succeeded_at":  nil,
+		"failed_at":     nil,
+		"cancelled_at":  nil,
+		"next_retry_at": nil,
+		"requeued_at":   time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !reset {
+		return nil, fmt.Errorf("%w: task %d was changed concurrently", ErrInvalidTransition, task.ID)
+	}
+
+	task, err = s.repo.GetByIDWithAsset(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.dispatchTask(task, asset, template); err != nil {
+		log.Printf("%v", err)
+	}
+	return s.GetTask(id)
+}
+
+// finishedAt returns when a task reached its terminal status
+func finishedAt(task *models.Task) *time.Time {
+	switch task.Status {
+	case models.TaskStatusSucceeded:
+		return task.SucceededAt
+	case models.TaskStatusFailed:
+		return task.FailedAt
+	case models.TaskStatusCancelled:
+		return task.CancelledAt
+	}
+	return nil
+}
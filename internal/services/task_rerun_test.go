@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"screensaver-ad-backend/internal/models"
+)
+
+func TestCancelledTaskIgnoresLateEvents(t *testing.T) {
+	env := newTaskTestEnv(t)
+	task := env.createTask(t)
+
+	if _, err := env.service.CancelTask(task.ID); err != nil {
+		t.Fatalf("CancelTask: %v", err)
+	}
+	env.event(t, task, TaskEventProcessed, map[string]interface{}{"s3_key": "output/late.mp4"})
+
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusCancelled)
+	var asset models.Asset
+	if err := env.db.First(&asset, env.asset.ID).Error; err != nil {
+		t.Fatalf("reload asset: %v", err)
+	}
+	if asset.OutputS3Key != nil {
+		t.Errorf("late event of a cancelled task set the asset output to %s", *asset.OutputS3Key)
+	}
+
+	if _, err := env.service.CancelTask(task.ID); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("cancelling twice: err = %v, want ErrInvalidTransition", err)
+	}
+}
+
+func TestRerunTaskStartsNewRun(t *testing.T) {
+	env := newTaskTestEnv(t)
+	task := env.createTask(t)
+	firstOutput := fmt.Sprintf("output/%d/task-%d.mp4", env.asset.ID, task.ID)
+	env.event(t, task, TaskEventProcessed, map[string]interface{}{"s3_key": firstOutput, "run": float64(1)})
+
+	rerun, err := env.service.RerunTask(task.ID)
+	if err != nil {
+		t.Fatalf("RerunTask: %v", err)
+	}
+	assertStatus(t, rerun, models.TaskStatusQueued)
+	if rerun.Run != 2 || rerun.Attempts != 0 || rerun.Metadata != nil || rerun.SucceededAt != nil {
+		t.Errorf("re-run task = run %d, attempts %d, metadata %v, succeeded_at %v", rerun.Run, rerun.Attempts, rerun.Metadata, rerun.SucceededAt)
+	}
+	if len(rerun.Runs) != 1 || rerun.Runs[0].Run != 1 || rerun.Runs[0].Status != models.TaskStatusSucceeded || rerun.Runs[0].OutputS3Key != firstOutput {
+		t.Errorf("archived runs = %+v", rerun.Runs)
+	}
+
+	jobs := env.transport.Jobs()
+	secondOutput := fmt.Sprintf("output/%d/task-%d-run-2.mp4", env.asset.ID, task.ID)
+	if len(jobs) != 2 || jobs[1].Run != 2 || jobs[1].OutputKey != secondOutput {
+		t.Fatalf("jobs = %+v", jobs)
+	}
+
+	// A late event of the first run does not touch the second
+	env.event(t, task, TaskEventFailed, map[string]interface{}{"error": "late", "run": float64(1)})
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusQueued)
+
+	env.event(t, task, TaskEventProcessed, map[string]interface{}{"s3_key": secondOutput, "run": float64(2)})
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusSucceeded)
+}
+
+func TestRerunRequiresFinishedTask(t *testing.T) {
+	env := newTaskTestEnv(t)
+	task := env.createTask(t)
+
+	if _, err := env.service.RerunTask(task.ID); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("re-running a queued task: err = %v, want ErrInvalidTransition", err)
+	}
+
+	if _, err := env.service.CancelTask(task.ID); err != nil {
+		t.Fatalf("CancelTask: %v", err)
+	}
+	rerun, err := env.service.RerunTask(task.ID)
+	if err != nil {
+		t.Fatalf("RerunTask of a cancelled task: %v", err)
+	}
+	if rerun.Run != 2 || len(rerun.Runs) != 1 || rerun.Runs[0].Status != models.TaskStatusCancelled {
+		t.Errorf("re-run task = run %d, runs %+v", rerun.Run, rerun.Runs)
+	}
+}
+
+func TestEventsWithoutRunAreIgnoredAfterRerun(t *testing.T) {
+	env := newTaskTestEnv(t)
+	task := env.createTask(t)
+
+	// Before any re-run a worker that does not echo run is still understood
+	env.event(t, task, TaskEventProcessed, map[string]interface{}{"s3_key": "output/first.mp4"})
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusSucceeded)
+
+	if _, err := env.service.RerunTask(task.ID); err != nil {
+		t.Fatalf("RerunTask: %v", err)
+	}
+	env.event(t, task, TaskEventProcessed, map[string]interface{}{"s3_key": "output/late.mp4"})
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusQueued)
+
+	var asset models.Asset
+	if err := env.db.First(&asset, env.asset.ID).Error; err != nil {
+		t.Fatalf("reload asset: %v", err)
+	}
+	if asset.OutputS3Key == nil || *asset.OutputS3Key != "output/first.mp4" {
+		t.Errorf("asset output = %v, want the output of the first run", asset.OutputS3Key)
+	}
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"screensaver-ad-backend/config"
+	"screensaver-ad-backend/internal/models"
+)
+
+// ErrInvalidRetryPolicy is returned when a template retry policy is out of range
+var ErrInvalidRetryPolicy = errors.New("invalid retry policy")
+
+// retryPolicy is a template's retry policy with the configured defaults applied
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	jitter      float64
+}
+
+// effectiveRetryPolicy merges the retry policy of a template (nil for defaults only)
+// with the TASK_RETRY_* defaults
+func effectiveRetryPolicy(template *models.Template) retryPolicy {
+	defaults := config.GetTaskRetryConfig()
+	policy := retryPolicy{
+		maxAttempts: defaults.MaxAttempts,
+		baseDelay:   defaults.BaseDelay,
+		maxDelay:    defaults.MaxDelay,
+		jitter:      defaults.Jitter,
+	}
+	if template == nil {
+		return policy
+	}
+
+	p := template.RetryPolicy
+	if p.MaxAttempts != nil {
+		policy.maxAttempts = *p.MaxAttempts
+	}
+	if p.BaseDelay != nil {
+		policy.baseDelay = seconds(*p.BaseDelay)
+	}
+	if p.MaxDelay != nil {
+		policy.maxDelay = seconds(*p.MaxDelay)
+	}
+	if p.Jitter != nil {
+		policy.jitter = *p.Jitter
+	}
+	return policy
+}
+
+// delay returns the wait before the next attempt after the given number of failed
+// attempts: the base delay doubled per attempt, randomized by the jitter and capped
+// at the maximum delay
+func (p retryPolicy) delay(attempts int) time.Duration {
+	d := float64(p.baseDelay) * math.Pow(2, float64(attempts-1))
+	d *= 1 + p.jitter*(2*rand.Float64()-1)
+	if limit := float64(p.maxDelay); p.maxDelay > 0 && d > limit {
+		d = limit
+	}
+	return time.Duration(d)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// validateRetryPolicy checks that a template retry policy is usable
+func validateRetryPolicy(p models.RetryPolicy) error {
+	if p.MaxAttempts != nil && *p.MaxAttempts < 1 {
+		return fmt.Errorf("%w: max_attempts must be at least 1", ErrInvalidRetryPolicy)
+	}
+	if p.BaseDelay != nil && *p.BaseDelay <= 0 {
+		return fmt.Errorf("%w: base_delay must be positive", ErrInvalidRetryPolicy)
+	}
+	if p.MaxDelay != nil && *p.MaxDelay <= 0 {
+		return fmt.Errorf("%w: max_delay must be positive", ErrInvalidRetryPolicy)
+	}
+	if p.BaseDelay != nil && p.MaxDelay != nil && *p.MaxDelay < *p.BaseDelay {
+		return fmt.Errorf("%w: max_delay must not be shorter than base_delay", ErrInvalidRetryPolicy)
+	}
+	if p.Jitter != nil && (*p.Jitter < 0 || *p.Jitter > 1) {
+		return fmt.Errorf("%w: jitter must be between 0 and 1", ErrInvalidRetryPolicy)
+	}
+	return nil
+}
+
+// failTask records a failed attempt. While the template's retry policy allows more
+// attempts the task is scheduled for a retry; otherwise it ends failed with message.
+func (s *TaskService) failTask(task *models.Task, message string) error {
+	var template *models.Template
+	if t, err := s.templateRepo.GetByID(task.TemplateID); err == nil {
+		template = t
+	}
+	policy := effectiveRetryPolicy(template)
+
+	// A task that fails before it starts has still used an attempt
+	attempts := task.Attempts
+	if task.Status == models.TaskStatusQueued {
+		attempts++
+	}
+	// Tasks of deleted templates cannot be re-issued
+	if template == nil || attempts >= policy.maxAttempts {
+		return s.transition(task, models.TaskStatusFailed, message)
+	}
+
+	next := time.Now().Add(policy.delay(attempts))
+	task.NextRetryAt = &next
+	return s.transition(task, models.TaskStatusRetryScheduled, message)
+}
+
+// RetryDueTasks re-issues the tasks whose retry is due. Without a worker transport they
+// return to pending for the external poller. A retry that cannot be sent is rescheduled
+// until the run has DISPATCH_MAX_ATTEMPTS failed dispatches; then the task fails.
+func (s *TaskService) RetryDueTasks() error {
+	tasks, err := s.repo.ListDueRetries(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for i := range tasks {
+		task := &tasks[i]
+		if s.transport == nil {
+			if err := s.transition(task, models.TaskStatusPending, ""); err != nil {
+				log.Printf("Failed to requeue task %d: %v", task.ID, err)
+			}
+			continue
+		}
+
+		asset, err := s.assetRepo.GetByID(task.AssetID)
+		if err != nil {
+			s.abandonRetry(task, "asset no longer exists")
+			continue
+		}
+		template, err := s.templateRepo.GetByID(task.TemplateID)
+		if err != nil {
+			s.abandonRetry(task, "template no longer exists")
+			continue
+		}
+
+		if err := s.dispatchTask(task, asset, template); err != nil {
+			log.Printf("%v", err)
+			s.rescheduleRetry(task, template, err)
+		}
+	}
+	return nil
+}
+
+// rescheduleRetry schedules another attempt to send a retry that could not be sent, or
+// fails the task once its run has used up the dispatch attempts
+func (s *TaskService) rescheduleRetry(task *models.Task, template *models.Template, sendErr error) {
+	failures, err := s.repo.CountFailedDispatches(task.ID, task.Run)
+	if err != nil {
+		log.Printf("Failed to count dispatches of task %d: %v", task.ID, err)
+	} else if int(failures) >= config.GetDispatchConfig().MaxAttempts {
+		s.abandonRetry(task, fmt.Sprintf("retry could not be sent after %d attempts: %v", failures, sendErr))
+		return
+	}
+
+	next := time.Now().Add(effectiveRetryPolicy(template).delay(task.Attempts))
+	if _, err := s.repo.Transition(task.ID, models.TaskStatusRetryScheduled, map[string]interface{}{"next_retry_at": next}); err != nil {
+		log.Printf("Failed to reschedule task %d: %v", task.ID, err)
+	}
+}
+
+// abandonRetry fails a scheduled task that can no longer be retried
+func (s *TaskService) abandonRetry(task *models.Task, message string) {
+	if err := s.transition(task, models.TaskStatusFailed, message); err != nil {
+		log.Printf("Failed to fail task %d: %v", task.ID, err)
+	}
+}
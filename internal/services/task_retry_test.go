@@ -0,0 +1,122 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"screensaver-ad-backend/internal/models"
+)
+
+func TestFailedTaskIsRetried(t *testing.T) {
+	env := newTaskTestEnv(t)
+	task := env.createTask(t)
+
+	env.event(t, task, TaskEventStarted, nil)
+	env.event(t, task, TaskEventFailed, map[string]interface{}{"error": "render crashed"})
+	got := env.reload(t, task.ID)
+	assertStatus(t, got, models.TaskStatusRetryScheduled)
+	if got.Error != "render crashed" || got.NextRetryAt == nil || got.Attempts != 1 {
+		t.Fatalf("scheduled task = error %q, next_retry_at %v, attempts %d", got.Error, got.NextRetryAt, got.Attempts)
+	}
+
+	// Retries that are not due yet are left alone
+	if err := env.service.RetryDueTasks(); err != nil {
+		t.Fatalf("RetryDueTasks: %v", err)
+	}
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusRetryScheduled)
+
+	env.set(t, task.ID, "next_retry_at", time.Now().Add(-time.Second))
+	if err := env.service.RetryDueTasks(); err != nil {
+		t.Fatalf("RetryDueTasks: %v", err)
+	}
+	got = env.reload(t, task.ID)
+	assertStatus(t, got, models.TaskStatusQueued)
+	if got.NextRetryAt != nil {
+		t.Errorf("next_retry_at = %v after the retry was sent", got.NextRetryAt)
+	}
+	if jobs := env.transport.Jobs(); len(jobs) != 2 || jobs[1].Run != 1 {
+		t.Errorf("jobs = %+v, want the same run sent twice", jobs)
+	}
+}
+
+func TestTaskFailsWhenRetriesAreExhausted(t *testing.T) {
+	env := newTaskTestEnv(t)
+	maxAttempts := 2
+	env.template.RetryPolicy.MaxAttempts = &maxAttempts
+	if err := env.db.Save(env.template).Error; err != nil {
+		t.Fatalf("save template: %v", err)
+	}
+	task := env.createTask(t)
+
+	env.event(t, task, TaskEventFailed, map[string]interface{}{"error": "first"})
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusRetryScheduled)
+
+	env.set(t, task.ID, "next_retry_at", time.Now().Add(-time.Second))
+	if err := env.service.RetryDueTasks(); err != nil {
+		t.Fatalf("RetryDueTasks: %v", err)
+	}
+	env.event(t, task, TaskEventStarted, nil)
+	env.event(t, task, TaskEventFailed, map[string]interface{}{"error": "second"})
+
+	got := env.reload(t, task.ID)
+	assertStatus(t, got, models.TaskStatusFailed)
+	if got.Attempts != 2 || got.Error != "second" || got.NextRetryAt != nil {
+		t.Errorf("failed task = attempts %d, error %q, next_retry_at %v", got.Attempts, got.Error, got.NextRetryAt)
+	}
+}
+
+func TestRetryDelayBackoff(t *testing.T) {
+	policy := retryPolicy{maxAttempts: 5, baseDelay: time.Second, maxDelay: 5 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := policy.delay(i + 1); got != w {
+			t.Errorf("delay(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+
+	policy.jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := policy.delay(1); d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("delay with jitter 0.5 = %s, want within 0.5s-1.5s", d)
+		}
+	}
+}
+
+func TestUnsentRetryFailsAfterMaxDispatchAttempts(t *testing.T) {
+	env := newTaskTestEnv(t)
+	task := env.createTask(t)
+	env.event(t, task, TaskEventFailed, map[string]interface{}{"error": "render crashed"})
+
+	env.transport.FailWith(errors.New("worker unavailable"))
+	for i := 1; i <= 3; i++ {
+		assertStatus(t, env.reload(t, task.ID), models.TaskStatusRetryScheduled)
+		env.set(t, task.ID, "next_retry_at", time.Now().Add(-time.Second))
+		if err := env.service.RetryDueTasks(); err != nil {
+			t.Fatalf("RetryDueTasks: %v", err)
+		}
+	}
+
+	// The first dispatch succeeded; three failed sends use up DISPATCH_MAX_ATTEMPTS
+	got := env.reload(t, task.ID)
+	assertStatus(t, got, models.TaskStatusFailed)
+	if !strings.Contains(got.Error, "worker unavailable") || got.NextRetryAt != nil {
+		t.Errorf("failed task = error %q, next_retry_at %v", got.Error, got.NextRetryAt)
+	}
+}
+
+func TestRetryOfDeletedTemplateFails(t *testing.T) {
+	env := newTaskTestEnv(t)
+	task := env.createTask(t)
+	env.event(t, task, TaskEventFailed, map[string]interface{}{"error": "render crashed"})
+
+	if err := env.db.Delete(env.template).Error; err != nil {
+		t.Fatalf("delete template: %v", err)
+	}
+	env.set(t, task.ID, "next_retry_at", time.Now().Add(-time.Second))
+	if err := env.service.RetryDueTasks(); err != nil {
+		t.Fatalf("RetryDueTasks: %v", err)
+	}
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusFailed)
+}
@@ -1,72 +1,318 @@
 package services
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"time"
+
+	"screensaver-ad-backend/internal/dispatch"
 	"screensaver-ad-backend/internal/models"
 	"screensaver-ad-backend/internal/repository"
+)
+
+var (
+	// ErrTaskNotFound is returned when the requested task does not exist
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrInvalidTransition is returned when a task cannot move to the requested status
+	ErrInvalidTransition = errors.New("invalid task status transition")
+	// ErrInvalidTaskEvent is returned for worker events without a valid task_id
+	ErrInvalidTaskEvent = errors.New("task_id not found or invalid in payload")
+)
 
-	"gorm.io/gorm"
+// Worker events reported through the webhook
+const (
+	TaskEventQueued    = "queued"
+	TaskEventStarted   = "started"
+	TaskEventProcessed = "processed"
+	TaskEventFailed    = "failed"
 )
 
+// taskProgress orders the non-terminal states a task passes through
+var taskProgress = []models.TaskStatus{models.TaskStatusPending, models.TaskStatusQueued, models.TaskStatusRunning}
+
 // TaskService handles business logic for tasks
 type TaskService struct {
-	repo      *repository.TaskRepository
-	assetRepo *repository.AssetRepository
+	repo           *repository.TaskRepository
+	assetRepo      *repository.AssetRepository
+	templateRepo   *repository.TemplateRepository
+	storageService *StorageService
+	transport      dispatch.Transport
 }
 
-// NewTaskService creates a new task service instance
-func NewTaskService(repo *repository.TaskRepository, assetRepo *repository.AssetRepository) *TaskService {
+// NewTaskService creates a new task service instance. New tasks are sent to the worker
+// through transport; a nil transport leaves them pending.
+func NewTaskService(repo *repository.TaskRepository, assetRepo *repository.AssetRepository, templateRepo *repository.TemplateRepository, storageService *StorageService, transport dispatch.Transport) *TaskService {
 	return &TaskService{
-		repo:      repo,
-		assetRepo: assetRepo,
+		repo:           repo,
+		assetRepo:      assetRepo,
+		templateRepo:   templateRepo,
+		storageService: storageService,
+		transport:      transport,
 	}
 }
 
 // CreateTaskIfNotExists creates a task if no record exists with same asset and template IDs
+// and dispatches it to the worker; otherwise task is set to the existing task. The asset
+// must meet the template requirements; otherwise a *CompatibilityError lists every
+// violated rule. A failed dispatch does not fail the creation; the task is resent by
+// DispatchPendingTasks.
 func (s *TaskService) CreateTaskIfNotExists(task *models.Task) (bool, error) {
-	// Check if task already exists
-	_, err := s.repo.FindByAssetAndTemplate(task.AssetID, task.TemplateID)
-	if err == nil {
-		// Task already exists
-		return false, nil
-	}
-	if err != gorm.ErrRecordNotFound {
-		// Database error
-		return false, err
+	asset, err := s.assetRepo.GetByID(task.AssetID)
+	if err != nil {
+		return false, ErrAssetNotFound
+	}
+	template, err := s.templateRepo.GetByID(task.TemplateID)
+	if err != nil {
+		return false, ErrTemplateNotFound
+	}
+	if violations := checkCompatibility(asset, template); len(violations) > 0 {
+		return false, &CompatibilityError{Violations: violations}
 	}
 
-	// Task doesn't exist, create it
-	if err := s.repo.Create(task); err != nil {
+	// An existing task is returned as is; use RerunTask to process it again
+	task.Status = models.TaskStatusPending
+	created, err := s.repo.CreateIfNotExists(task)
+	if err != nil || !created {
 		return false, err
 	}
+	if err := s.dispatchTask(task, asset, template); err != nil {
+		log.Printf("%v", err)
+	}
 	return true, nil
 }
 
-// UpdateTaskMetadata updates task metadata and corresponding asset based on payload
-func (s *TaskService) UpdateTaskMetadata(payload map[string]interface{}) error {
+// HandleWorkerEvent applies a worker event to the task named by payload["task_id"] and
+// returns the updated task. Workers may skip events: a task is first advanced through
+// the states it missed, e.g. a pending task that is reported processed is queued and
+// started before it succeeds. Repeated events, events of cancelled tasks and events
+// whose payload["run"] is not the current run are ignored; after a re-run, events
+// without payload["run"] are ignored as well. A timed-out task only accepts processed.
+func (s *TaskService) HandleWorkerEvent(eventType string, payload map[string]interface{}) (*models.Task, error) {
 	// Extract task_id from payload
 	taskIDFloat, ok := payload["task_id"].(float64)
 	if !ok {
-		return fmt.Errorf("task_id not found or invalid in payload")
+		return nil, ErrInvalidTaskEvent
 	}
-	taskID := uint(taskIDFloat)
 
 	// Get task with asset relation
-	task, err := s.repo.GetByIDWithAsset(taskID)
+	task, err := s.repo.GetByIDWithAsset(uint(taskIDFloat))
 	if err != nil {
-		return err
+		return nil, ErrTaskNotFound
 	}
 
-	// Update task metadata
-	task.Metadata = payload
-	if err := s.repo.Update(task); err != nil {
+	// Late events of cancelled tasks and of runs replaced by a re-run are ignored
+	if task.Status == models.TaskStatusCancelled {
+		return task, nil
+	}
+	if !isCurrentRun(task, payload) {
+		return task, nil
+	}
+
+	switch eventType {
+	case TaskEventQueued:
+		return task, s.advance(task, models.TaskStatusQueued)
+	case TaskEventStarted:
+		return task, s.advance(task, models.TaskStatusRunning)
+	case TaskEventFailed:
+		if task.Status == models.TaskStatusFailed || task.Status == models.TaskStatusRetryScheduled || task.Status == models.TaskStatusTimedOut {
+			return task, nil
+		}
+		if err := s.advance(task, models.TaskStatusQueued); err != nil {
+			return nil, err
+		}
+		message, _ := payload["error"].(string)
+		return task, s.failTask(task, message)
+	case TaskEventProcessed:
+		if task.Status == models.TaskStatusSucceeded {
+			return task, nil
+		}
+		// A worker that finishes after the timeout still delivers its output
+		if task.Status != models.TaskStatusTimedOut {
+			if err := s.advance(task, models.TaskStatusRunning); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.succeed(task, payload); err != nil {
+			return nil, err
+		}
+		return task, nil
+	}
+	return nil, fmt.Errorf("unknown task event %q", eventType)
+}
+
+// isCurrentRun reports whether an event belongs to the current run of task. Events
+// without a run are only accepted before the first re-run, since a late event of an
+// earlier run could not be told apart from the current one.
+func isCurrentRun(task *models.Task, payload map[string]interface{}) bool {
+	run, ok := payload["run"].(float64)
+	if !ok {
+		return task.Run <= 1
+	}
+	return int(run) == task.Run
+}
+
+// succeed marks a task succeeded and stores the worker payload as its metadata in the
+// same conditional update. The output is recorded on the asset only after that update,
+// so a task cancelled or re-run in the meantime never overwrites the asset's output.
+func (s *TaskService) succeed(task *models.Task, payload map[string]interface{}) error {
+	// Map updates bypass the JSON serializer of Metadata, so the payload is encoded here
+	metadata, err := json.Marshal(payload)
+	if err != nil {
 		return err
 	}
+	if err := s.transitionWith(task, models.TaskStatusSucceeded, "", map[string]interface{}{"metadata": string(metadata)}); err != nil {
+		return err
+	}
+	task.Metadata = payload
 
 	// Extract s3_key and update asset if present
 	if s3Key, exists := payload["s3_key"].(string); exists {
 		return s.assetRepo.UpdateOutputS3Key(task.AssetID, s3Key)
 	}
+	return nil
+}
 
+// advance moves a task forward through the non-terminal states until it reaches target.
+// Tasks already at or past target are left unchanged.
+func (s *TaskService) advance(task *models.Task, target models.TaskStatus) error {
+	if task.Status.Terminal() {
+		return fmt.Errorf("%w: task %d is %s", ErrInvalidTransition, task.ID, task.Status)
+	}
+	for i := progressIndex(task.Status) + 1; i <= progressIndex(target); i++ {
+		if err := s.transition(task, taskProgress[i], ""); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+func progressIndex(status models.TaskStatus) int {
+	// A scheduled retry starts over like a new task
+	if status == models.TaskStatusRetryScheduled {
+		return 0
+	}
+	for i, s := range taskProgress {
+		if s == status {
+			return i
+		}
+	}
+	return len(taskProgress)
+}
+
+// transition moves a task to next, validating the state machine and recording the
+// transition time. Starting a task counts an attempt; failing or timing it out records
+// message. Scheduling a retry stores task.NextRetryAt.
+func (s *TaskService) transition(task *models.Task, next models.TaskStatus, message string) error {
+	return s.transitionWith(task, next, message, nil)
+}
+
+// transitionWith is transition with extra column updates applied in the same conditional update
+func (s *TaskService) transitionWith(task *models.Task, next models.TaskStatus, message string, extra map[string]interface{}) error {
+	if !task.Status.CanTransitionTo(next) {
+		return fmt.Errorf("%w: task %d cannot move from %s to %s", ErrInvalidTransition, task.ID, task.Status, next)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": next}
+	for column, value := range extra {
+		updates[column] = value
+	}
+	switch next {
+	case models.TaskStatusQueued:
+		updates["queued_at"] = now
+		updates["next_retry_at"] = nil
+		task.QueuedAt = &now
+		task.NextRetryAt = nil
+	case models.TaskStatusRunning:
+		updates["started_at"] = now
+		updates["attempts"] = task.Attempts + 1
+		updates["error"] = ""
+		task.StartedAt = &now
+		task.Attempts++
+		task.Error = ""
+	case models.TaskStatusSucceeded:
+		updates["succeeded_at"] = now
+		task.SucceededAt = &now
+	case models.TaskStatusFailed, models.TaskStatusRetryScheduled:
+		updates["failed_at"] = now
+		updates["error"] = message
+		updates["next_retry_at"] = task.NextRetryAt
+		task.FailedAt = &now
+		task.Error = message
+		if next == models.TaskStatusFailed {
+			updates["next_retry_at"] = nil
+			task.NextRetryAt = nil
+		}
+		// A task that fails before it starts has still used an attempt
+		if task.Status == models.TaskStatusQueued {
+			updates["attempts"] = task.Attempts + 1
+			task.Attempts++
+		}
+	case models.TaskStatusCancelled:
+		updates["cancelled_at"] = now
+		task.CancelledAt = &now
+	case models.TaskStatusTimedOut:
+		updates["timed_out_at"] = now
+		updates["error"] = message
+		task.TimedOutAt = &now
+		task.Error = message
+		if task.Status == models.TaskStatusQueued {
+			updates["attempts"] = task.Attempts + 1
+			task.Attempts++
+		}
+	case models.TaskStatusPending:
+		updates["requeued_at"] = now
+		task.RequeuedAt = &now
+		// A requeued task starts with a fresh retry budget
+		if task.Status == models.TaskStatusTimedOut {
+			updates["attempts"] = 0
+			updates["error"] = ""
+			task.Attempts = 0
+			task.Error = ""
+		}
+	}
+
+	updated, err := s.repo.Transition(task.ID, task.Status, updates)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return fmt.Errorf("%w: task %d was changed concurrently", ErrInvalidTransition, task.ID)
+	}
+	task.Status = next
+	return nil
+}
+
+// ListTasks returns the tasks matching filter, newest first, and the total number of matches
+func (s *TaskService) ListTasks(filter repository.TaskFilter, limit, offset int) ([]models.Task, int64, error) {
+	return s.repo.List(filter, limit, offset)
+}
+
+// ListAssetTasks lists the tasks of an asset like ListTasks
+func (s *TaskService) ListAssetTasks(assetID uint, filter repository.TaskFilter, limit, offset int) ([]models.Task, int64, error) {
+	if _, err := s.assetRepo.GetByID(assetID); err != nil {
+		return nil, 0, ErrAssetNotFound
+	}
+	filter.AssetID = assetID
+	return s.repo.List(filter, limit, offset)
+}
+
+// ListTemplateTasks lists the tasks of a template like ListTasks
+func (s *TaskService) ListTemplateTasks(templateID uint, filter repository.TaskFilter, limit, offset int) ([]models.Task, int64, error) {
+	if _, err := s.templateRepo.GetByID(templateID); err != nil {
+		return nil, 0, ErrTemplateNotFound
+	}
+	filter.TemplateID = templateID
+	return s.repo.List(filter, limit, offset)
+}
+
+// GetTask returns a task with its asset and template
+func (s *TaskService) GetTask(id uint) (*models.Task, error) {
+	task, err := s.repo.GetByIDWithRelations(id)
+	if err != nil {
+		return nil, ErrTaskNotFound
+	}
+	return task, nil
+}
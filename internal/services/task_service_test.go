@@ -0,0 +1,192 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"screensaver-ad-backend/config"
+	"screensaver-ad-backend/internal/dispatch"
+	"screensaver-ad-backend/internal/models"
+	"screensaver-ad-backend/internal/repository"
+	"screensaver-ad-backend/internal/storage"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// taskTestEnv wires a TaskService to an in-memory SQLite database, local storage and
+// an in-memory worker transport, with one compatible asset and template
+type taskTestEnv struct {
+	db        *gorm.DB
+	service   *TaskService
+	transport *dispatch.MemoryTransport
+	asset     *models.Asset
+	template  *models.Template
+}
+
+func newTaskTestEnv(t *testing.T) *taskTestEnv {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	// Every connection to :memory: is a separate database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(models.Models()...); err != nil {
+		t.Fatalf("migrate database: %v", err)
+	}
+
+	config.OutputPrefix = "output"
+	config.Dispatch = config.DispatchConfig{URLExpiry: time.Hour, MaxAttempts: 3}
+	config.TaskRetry = config.TaskRetryConfig{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	config.TaskTimeout = config.TaskTimeoutConfig{Timeout: time.Hour}
+
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/storage", []byte("secret"))
+	if err != nil {
+		t.Fatalf("create storage: %v", err)
+	}
+
+	env := &taskTestEnv{
+		db:        db,
+		transport: dispatch.NewMemoryTransport(),
+		asset: &models.Asset{
+			FileName:    "poster.png",
+			FileSize:    1024,
+			ContentType: "image/png",
+			S3Key:       "input/poster.png",
+			S3Bucket:    "local",
+			Status:      models.AssetStatusUploaded,
+		},
+		template: &models.Template{Name: "loop", S3Key: "template/loop.mp4", S3Bucket: "local"},
+	}
+	if err := db.Create(env.asset).Error; err != nil {
+		t.Fatalf("create asset: %v", err)
+	}
+	if err := db.Create(env.template).Error; err != nil {
+		t.Fatalf("create template: %v", err)
+	}
+
+	env.service = NewTaskService(
+		repository.NewTaskRepository(db),
+		repository.NewAssetRepository(db),
+		repository.NewTemplateRepository(db),
+		NewStorageService(store, nil),
+		env.transport,
+	)
+	return env
+}
+
+// createTask creates and dispatches the task of the test asset and template
+func (e *taskTestEnv) createTask(t *testing.T) *models.Task {
+	t.Helper()
+	task := &models.Task{AssetID: e.asset.ID, TemplateID: e.template.ID}
+	created, err := e.service.CreateTaskIfNotExists(task)
+	if err != nil {
+		t.Fatalf("CreateTaskIfNotExists: %v", err)
+	}
+	if !created {
+		t.Fatalf("CreateTaskIfNotExists returned an existing task")
+	}
+	return task
+}
+
+// event delivers a worker event for task
+func (e *taskTestEnv) event(t *testing.T, task *models.Task, eventType string, payload map[string]interface{}) {
+	t.Helper()
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+	payload["task_id"] = float64(task.ID)
+	if _, err := e.service.HandleWorkerEvent(eventType, payload); err != nil {
+		t.Fatalf("HandleWorkerEvent(%s): %v", eventType, err)
+	}
+}
+
+// reload reads a task back from the database
+func (e *taskTestEnv) reload(t *testing.T, id uint) *models.Task {
+	t.Helper()
+	var task models.Task
+	if err := e.db.First(&task, id).Error; err != nil {
+		t.Fatalf("reload task %d: %v", id, err)
+	}
+	return &task
+}
+
+// set overwrites a column of a task, e.g. to move its timestamps into the past
+func (e *taskTestEnv) set(t *testing.T, id uint, column string, value interface{}) {
+	t.Helper()
+	if err := e.db.Model(&models.Task{}).Where("id = ?", id).UpdateColumn(column, value).Error; err != nil {
+		t.Fatalf("set %s of task %d: %v", column, id, err)
+	}
+}
+
+// dispatches returns the dispatch records of a task, oldest first
+func (e *taskTestEnv) dispatches(t *testing.T, id uint) []models.TaskDispatch {
+	t.Helper()
+	var records []models.TaskDispatch
+	if err := e.db.Where("task_id = ?", id).Order("id").Find(&records).Error; err != nil {
+		t.Fatalf("list dispatches of task %d: %v", id, err)
+	}
+	return records
+}
+
+func assertStatus(t *testing.T, task *models.Task, want models.TaskStatus) {
+	t.Helper()
+	if task.Status != want {
+		t.Fatalf("task %d status = %s, want %s (error: %q)", task.ID, task.Status, want, task.Error)
+	}
+}
+
+func TestWorkerEventsCompleteTask(t *testing.T) {
+	env := newTaskTestEnv(t)
+	task := env.createTask(t)
+
+	env.event(t, task, TaskEventStarted, nil)
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusRunning)
+
+	env.event(t, task, TaskEventProcessed, map[string]interface{}{"s3_key": "output/1/task-1.mp4"})
+	got := env.reload(t, task.ID)
+	assertStatus(t, got, models.TaskStatusSucceeded)
+	if got.Attempts != 1 || got.SucceededAt == nil {
+		t.Errorf("succeeded task = attempts %d, succeeded_at %v", got.Attempts, got.SucceededAt)
+	}
+	if got.Metadata["s3_key"] != "output/1/task-1.mp4" {
+		t.Errorf("metadata = %v", got.Metadata)
+	}
+
+	var asset models.Asset
+	if err := env.db.First(&asset, env.asset.ID).Error; err != nil {
+		t.Fatalf("reload asset: %v", err)
+	}
+	if asset.OutputS3Key == nil || *asset.OutputS3Key != "output/1/task-1.mp4" {
+		t.Errorf("asset output = %v", asset.OutputS3Key)
+	}
+
+	// A repeated event changes nothing
+	env.event(t, task, TaskEventProcessed, map[string]interface{}{"s3_key": "output/other.mp4"})
+	if got := env.reload(t, task.ID); got.Metadata["s3_key"] != "output/1/task-1.mp4" {
+		t.Errorf("repeated event overwrote metadata: %v", got.Metadata)
+	}
+}
+
+func TestWorkerEventSkipsMissedStates(t *testing.T) {
+	env := newTaskTestEnv(t)
+	env.service.transport = nil
+	task := env.createTask(t)
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusPending)
+
+	// A pending task reported processed is queued and started on the way
+	env.event(t, task, TaskEventProcessed, nil)
+	got := env.reload(t, task.ID)
+	assertStatus(t, got, models.TaskStatusSucceeded)
+	if got.QueuedAt == nil || got.StartedAt == nil || got.Attempts != 1 {
+		t.Errorf("skipped states not recorded: queued_at %v, started_at %v, attempts %d", got.QueuedAt, got.StartedAt, got.Attempts)
+	}
+}
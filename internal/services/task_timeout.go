@@ -0,0 +1,115 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"screensaver-ad-backend/config"
+	"screensaver-ad-backend/internal/models"
+	"screensaver-ad-backend/internal/repository"
+)
+
+// ErrInvalidTaskTimeout is returned when a template task timeout is not positive
+var ErrInvalidTaskTimeout = errors.New("task_timeout must be positive")
+
+// taskTimeout returns how long tasks of a template (nil for defaults only) may take
+// after they are queued, or created or requeued while pending; zero means they never
+// time out
+func taskTimeout(template *models.Template) time.Duration {
+	if template != nil && template.TaskTimeout != nil {
+		return seconds(*template.TaskTimeout)
+	}
+	return config.GetTaskTimeoutConfig().Timeout
+}
+
+// SweepTimedOutTasks marks pending, queued and running tasks that have not finished within
+// their template's timeout as timed_out, e.g. after a worker crashed mid-render or an
+// external poller never picked the task up. Timed-out tasks are listed by
+// ListDeadLetterTasks until they are requeued or cancelled.
+func (s *TaskService) SweepTimedOutTasks() error {
+	tasks, err := s.repo.ListInProgress()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	templates := map[uint]*models.Template{}
+	timedOut := 0
+	for i := range tasks {
+		task := &tasks[i]
+		template, ok := templates[task.TemplateID]
+		if !ok {
+			template, _ = s.templateRepo.GetByID(task.TemplateID)
+			templates[task.TemplateID] = template
+		}
+
+		timeout := taskTimeout(template)
+		if timeout <= 0 {
+			continue
+		}
+		since := pendingSince(task)
+		if task.Status != models.TaskStatusPending && task.QueuedAt != nil {
+			since = *task.QueuedAt
+		}
+		if now.Sub(since) < timeout {
+			continue
+		}
+
+		message := fmt.Sprintf("no completion from the worker within %s", timeout)
+		if err := s.transition(task, models.TaskStatusTimedOut, message); err != nil {
+			log.Printf("Failed to time out task %d: %v", task.ID, err)
+			continue
+		}
+		log.Printf("Task %d timed out: %s", task.ID, message)
+		timedOut++
+	}
+	if timedOut > 0 {
+		log.Printf("Sweep: %d tasks timed out and moved to the dead-letter listing", timedOut)
+	}
+	return nil
+}
+
+// pendingSince returns when a task last became pending
+func pendingSince(task *models.Task) time.Time {
+	if task.RequeuedAt != nil {
+		return *task.RequeuedAt
+	}
+	return task.CreatedAt
+}
+
+// ListDeadLetterTasks returns the timed-out tasks, newest first, and their total number
+func (s *TaskService) ListDeadLetterTasks(limit, offset int) ([]models.Task, int64, error) {
+	return s.repo.List(repository.TaskFilter{Statuses: []models.TaskStatus{models.TaskStatusTimedOut}}, limit, offset)
+}
+
+// RequeueTask moves a timed-out task back to pending with a fresh retry budget and
+// dispatches it to the worker. Without a transport it waits for the external poller. A
+// dispatch that fails is resent by DispatchPendingTasks.
+func (s *TaskService) RequeueTask(id uint) (*models.Task, error) {
+	task, err := s.repo.GetByIDWithAsset(id)
+	if err != nil {
+		return nil, ErrTaskNotFound
+	}
+	if task.Status != models.TaskStatusTimedOut {
+		return nil, fmt.Errorf("%w: task %d is %s; only timed_out tasks can be requeued", ErrInvalidTransition, task.ID, task.Status)
+	}
+
+	asset, err := s.assetRepo.GetByID(task.AssetID)
+	if err != nil {
+		return nil, ErrAssetNotFound
+	}
+	template, err := s.templateRepo.GetByID(task.TemplateID)
+	if err != nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	if err := s.transition(task, models.TaskStatusPending, ""); err != nil {
+		return nil, err
+	}
+	if err := s.dispatchTask(task, asset, template); err != nil {
+		log.Printf("%v", err)
+	}
+	return s.GetTask(id)
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"screensaver-ad-backend/internal/models"
+)
+
+func TestSweepTimesOutStuckTasks(t *testing.T) {
+	env := newTaskTestEnv(t)
+	stuck := env.createTask(t)
+
+	other := &models.Asset{FileName: "other.png", FileSize: 1, ContentType: "image/png", S3Key: "input/other.png", S3Bucket: "local", Status: models.AssetStatusUploaded}
+	if err := env.db.Create(other).Error; err != nil {
+		t.Fatalf("create asset: %v", err)
+	}
+	recent := &models.Task{AssetID: other.ID, TemplateID: env.template.ID}
+	if _, err := env.service.CreateTaskIfNotExists(recent); err != nil {
+		t.Fatalf("CreateTaskIfNotExists: %v", err)
+	}
+
+	env.set(t, stuck.ID, "queued_at", time.Now().Add(-2*time.Hour))
+	if err := env.service.SweepTimedOutTasks(); err != nil {
+		t.Fatalf("SweepTimedOutTasks: %v", err)
+	}
+
+	got := env.reload(t, stuck.ID)
+	assertStatus(t, got, models.TaskStatusTimedOut)
+	if got.TimedOutAt == nil || got.Error == "" {
+		t.Errorf("timed-out task = timed_out_at %v, error %q", got.TimedOutAt, got.Error)
+	}
+	assertStatus(t, env.reload(t, recent.ID), models.TaskStatusQueued)
+
+	dead, total, err := env.service.ListDeadLetterTasks(10, 0)
+	if err != nil {
+		t.Fatalf("ListDeadLetterTasks: %v", err)
+	}
+	if total != 1 || len(dead) != 1 || dead[0].ID != stuck.ID {
+		t.Errorf("dead letters = %d (total %d)", len(dead), total)
+	}
+}
+
+func TestSweepUsesTemplateTimeout(t *testing.T) {
+	env := newTaskTestEnv(t)
+	timeout := 60.0
+	env.template.TaskTimeout = &timeout
+	if err := env.db.Save(env.template).Error; err != nil {
+		t.Fatalf("save template: %v", err)
+	}
+	task := env.createTask(t)
+
+	env.set(t, task.ID, "queued_at", time.Now().Add(-30*time.Second))
+	if err := env.service.SweepTimedOutTasks(); err != nil {
+		t.Fatalf("SweepTimedOutTasks: %v", err)
+	}
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusQueued)
+
+	env.set(t, task.ID, "queued_at", time.Now().Add(-2*time.Minute))
+	if err := env.service.SweepTimedOutTasks(); err != nil {
+		t.Fatalf("SweepTimedOutTasks: %v", err)
+	}
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusTimedOut)
+}
+
+func TestTimedOutTaskAcceptsLateOutput(t *testing.T) {
+	env := newTaskTestEnv(t)
+	task := env.createTask(t)
+	env.event(t, task, TaskEventStarted, nil)
+	env.set(t, task.ID, "queued_at", time.Now().Add(-2*time.Hour))
+	if err := env.service.SweepTimedOutTasks(); err != nil {
+		t.Fatalf("SweepTimedOutTasks: %v", err)
+	}
+
+	// Only processed is accepted after the timeout
+	env.event(t, task, TaskEventFailed, map[string]interface{}{"error": "late failure"})
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusTimedOut)
+
+	env.event(t, task, TaskEventProcessed, map[string]interface{}{"s3_key": "output/late.mp4"})
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusSucceeded)
+}
+
+func TestRequeueTimedOutTask(t *testing.T) {
+	env := newTaskTestEnv(t)
+	task := env.createTask(t)
+
+	if _, err := env.service.RequeueTask(task.ID); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("requeueing a queued task: err = %v, want ErrInvalidTransition", err)
+	}
+
+	env.event(t, task, TaskEventStarted, nil)
+	env.set(t, task.ID, "queued_at", time.Now().Add(-2*time.Hour))
+	if err := env.service.SweepTimedOutTasks(); err != nil {
+		t.Fatalf("SweepTimedOutTasks: %v", err)
+	}
+
+	requeued, err := env.service.RequeueTask(task.ID)
+	if err != nil {
+		t.Fatalf("RequeueTask: %v", err)
+	}
+	assertStatus(t, requeued, models.TaskStatusQueued)
+	if requeued.Attempts != 0 || requeued.Error != "" {
+		t.Errorf("requeued task = attempts %d, error %q", requeued.Attempts, requeued.Error)
+	}
+	if len(env.transport.Jobs()) != 2 {
+		t.Errorf("sent %d jobs, want 2", len(env.transport.Jobs()))
+	}
+}
+
+func TestSweepTimesOutPendingTasks(t *testing.T) {
+	env := newTaskTestEnv(t)
+	env.service.transport = nil
+	task := env.createTask(t)
+
+	if err := env.service.SweepTimedOutTasks(); err != nil {
+		t.Fatalf("SweepTimedOutTasks: %v", err)
+	}
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusPending)
+
+	// No worker ever picked the task up
+	env.set(t, task.ID, "created_at", time.Now().Add(-2*time.Hour))
+	if err := env.service.SweepTimedOutTasks(); err != nil {
+		t.Fatalf("SweepTimedOutTasks: %v", err)
+	}
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusTimedOut)
+
+	// A requeued task is timed from the requeue
+	requeued, err := env.service.RequeueTask(task.ID)
+	if err != nil {
+		t.Fatalf("RequeueTask: %v", err)
+	}
+	if requeued.RequeuedAt == nil {
+		t.Fatalf("requeued task has no requeued_at")
+	}
+	if err := env.service.SweepTimedOutTasks(); err != nil {
+		t.Fatalf("SweepTimedOutTasks: %v", err)
+	}
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusPending)
+}
+
+func TestFailedRequeueDispatchIsResent(t *testing.T) {
+	env := newTaskTestEnv(t)
+	task := env.createTask(t)
+	env.set(t, task.ID, "queued_at", time.Now().Add(-2*time.Hour))
+	if err := env.service.SweepTimedOutTasks(); err != nil {
+		t.Fatalf("SweepTimedOutTasks: %v", err)
+	}
+
+	env.transport.FailWith(errors.New("worker unavailable"))
+	requeued, err := env.service.RequeueTask(task.ID)
+	if err != nil {
+		t.Fatalf("RequeueTask: %v", err)
+	}
+	assertStatus(t, requeued, models.TaskStatusPending)
+
+	// The dispatch sent before the timeout does not count for the requeue
+	env.transport.FailWith(nil)
+	if err := env.service.DispatchPendingTasks(); err != nil {
+		t.Fatalf("DispatchPendingTasks: %v", err)
+	}
+	assertStatus(t, env.reload(t, task.ID), models.TaskStatusQueued)
+	if jobs := env.transport.Jobs(); len(jobs) != 2 || jobs[1].Run != 1 {
+		t.Errorf("jobs = %+v, want the run sent again after the requeue", jobs)
+	}
+}
@@ -1,22 +1,124 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"io"
+
 	"screensaver-ad-backend/internal/models"
 	"screensaver-ad-backend/internal/repository"
 )
 
+var (
+	ErrTemplateNotFound    = errors.New("template not found")
+	ErrInvalidRequirements = errors.New("invalid template requirements")
+)
+
 type TemplateService struct {
-	repo *repository.TemplateRepository
+	repo           *repository.TemplateRepository
+	storageService *StorageService
 }
 
-func NewTemplateService(repo *repository.TemplateRepository) *TemplateService {
-	return &TemplateService{repo: repo}
+func NewTemplateService(repo *repository.TemplateRepository, storageService *StorageService) *TemplateService {
+	return &TemplateService{repo: repo, storageService: storageService}
 }
 
 func (s *TemplateService) CreateTemplate(template *models.Template) error {
 	return s.repo.Create(template)
 }
 
+// CreateTemplateWithUpload streams a template video to storage and creates its record
+func (s *TemplateService) CreateTemplateWithUpload(body io.Reader, originalName, contentType, name string) (*models.Template, error) {
+	// Templates must be videos, detected from the file signature
+	body, contentType, err := sniffContentType(body, contentType, isValidVideoType)
+	if err != nil {
+		return nil, err
+	}
+
+	uploaded, err := s.storageService.UploadFile(body, originalName, contentType, name, "template")
+	if err != nil {
+		if errors.Is(err, ErrFileTooLarge) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to upload to storage: %w", err)
+	}
+
+	// Read duration, resolution and codec; corrupt containers are rejected
+	video, err := probeVideo(s.storageService, uploaded.Key, contentType)
+	if err != nil {
+		_ = s.storageService.DeleteFile(uploaded.Key)
+		return nil, err
+	}
+
+	template := &models.Template{
+		Name:     name,
+		S3Key:    uploaded.Key,
+		S3Bucket: s.storageService.Bucket(),
+	}
+	if video != nil {
+		template.Duration = video.Duration
+		template.Width = video.Width
+		template.Height = video.Height
+		template.FrameRate = video.FrameRate
+		template.VideoCodec = video.Codec
+	}
+	if err := s.repo.Create(template); err != nil {
+		_ = s.storageService.DeleteFile(uploaded.Key)
+		return nil, fmt.Errorf("failed to save template: %w", err)
+	}
+
+	return template, nil
+}
+
 func (s *TemplateService) ListTemplates() ([]models.Template, error) {
 	return s.repo.List()
 }
+
+// UpdateRequirements replaces the requirements assets must meet to be used with a template
+func (s *TemplateService) UpdateRequirements(id uint, requirements models.TemplateRequirements) (*models.Template, error) {
+	if err := validateRequirements(requirements); err != nil {
+		return nil, err
+	}
+	template, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrTemplateNotFound
+	}
+	if err := s.repo.UpdateRequirements(id, requirements); err != nil {
+		return nil, err
+	}
+	template.Requirements = requirements
+	return template, nil
+}
+
+// UpdateRetryPolicy replaces the retry policy for failed tasks of a template
+func (s *TemplateService) UpdateRetryPolicy(id uint, policy models.RetryPolicy) (*models.Template, error) {
+	if err := validateRetryPolicy(policy); err != nil {
+		return nil, err
+	}
+	template, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrTemplateNotFound
+	}
+	if err := s.repo.UpdateRetryPolicy(id, policy); err != nil {
+		return nil, err
+	}
+	template.RetryPolicy = policy
+	return template, nil
+}
+
+// UpdateTaskTimeout sets how long tasks of a template may take before they time out.
+// A nil timeout restores the TASK_TIMEOUT default.
+func (s *TemplateService) UpdateTaskTimeout(id uint, timeout *float64) (*models.Template, error) {
+	if timeout != nil && *timeout <= 0 {
+		return nil, ErrInvalidTaskTimeout
+	}
+	template, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrTemplateNotFound
+	}
+	if err := s.repo.UpdateTaskTimeout(id, timeout); err != nil {
+		return nil, err
+	}
+	template.TaskTimeout = timeout
+	return template, nil
+}
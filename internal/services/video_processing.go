@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"screensaver-ad-backend/internal/media"
+	"screensaver-ad-backend/internal/models"
+)
+
+// probeVideo reads the container of a stored MP4/MOV file. Other video formats are
+// not probed and return nil. A corrupt container is reported as a ContentError.
+func probeVideo(storageService *StorageService, key, contentType string) (*media.VideoInfo, error) {
+	if !media.IsProbeable(contentType) {
+		return nil, nil
+	}
+
+	reader, info, err := storageService.OpenFile(context.Background(), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open video: %w", err)
+	}
+	defer reader.Close()
+
+	video, err := media.ProbeMP4(reader, info.Size)
+	if errors.Is(err, media.ErrCorruptContainer) {
+		return nil, &ContentError{Code: ErrCodeCorruptContainer, Message: err.Error()}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe video: %w", err)
+	}
+	return video, nil
+}
+
+func applyVideoInfo(asset *models.Asset, info *media.VideoInfo) {
+	asset.Duration = info.Duration
+	asset.Width = info.Width
+	asset.Height = info.Height
+	asset.FrameRate = info.FrameRate
+	asset.VideoCodec = info.Codec
+}
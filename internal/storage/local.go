@@ -0,0 +1,314 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// metaDir holds the JSON sidecar files with per-object metadata
+const metaDir = ".meta"
+
+// LocalStorage stores objects on the local filesystem and signs URLs that are
+// served back by the API server itself
+type LocalStorage struct {
+	root    string
+	baseURL string
+	secret  []byte
+}
+
+// localMeta is the metadata persisted next to each object
+type localMeta struct {
+	ContentType string `json:"content_type"`
+}
+
+// NewLocalStorage creates a new local filesystem storage backend.
+// baseURL is the public URL under which the server exposes the objects.
+func NewLocalStorage(root, baseURL string, secret []byte) (*LocalStorage, error) {
+	if err := os.MkdirAll(filepath.Join(root, metaDir), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &LocalStorage{
+		root:    root,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		secret:  secret,
+	}, nil
+}
+
+// Put writes an object to disk
+func (s *LocalStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Write to a temporary file first so readers never see partial objects
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, readerWithContext(ctx, body)); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	// Move the data into place before its metadata, so metadata never describes a missing object
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+	return s.writeMeta(key, localMeta{ContentType: contentType})
+}
+
+// Get opens an object on disk
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	info, err := s.Head(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	path, _ := s.path(key)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, s.wrapError(err)
+	}
+	return f, info, nil
+}
+
+// GetRange opens an object on disk positioned at offset
+func (s *LocalStorage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, s.wrapError(err)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if length < 0 {
+		return f, nil
+	}
+	return &limitedFile{Reader: io.LimitReader(f, length), f: f}, nil
+}
+
+// Delete removes an object and its metadata from disk
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	if err := os.Remove(s.metaPath(path)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete file metadata: %w", err)
+	}
+	return nil
+}
+
+// Head returns metadata for an object on disk
+func (s *LocalStorage) Head(ctx context.Context, key string) (*ObjectInfo, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, s.wrapError(err)
+	}
+	if stat.IsDir() {
+		return nil, ErrNotFound
+	}
+	return s.objectInfo(key, path, stat), nil
+}
+
+// PresignGet returns a signed URL served by the API server
+func (s *LocalStorage) PresignGet(ctx context.Context, key string, expire time.Duration) (string, error) {
+	return s.signedURL("GET", key, expire)
+}
+
+// PresignPut returns a signed upload URL served by the API server
+func (s *LocalStorage) PresignPut(ctx context.Context, key string, contentType string, expire time.Duration) (string, error) {
+	return s.signedURL("PUT", key, expire)
+}
+
+// List walks the storage directory and returns objects under prefix
+func (s *LocalStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == metaDir && filepath.Dir(path) == s.root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		stat, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, *s.objectInfo(key, path, stat))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	return objects, nil
+}
+
+// Bucket returns a fixed name identifying local storage
+func (s *LocalStorage) Bucket() string {
+	return "local"
+}
+
+// VerifySignature checks a signed URL previously produced by this backend
+func (s *LocalStorage) VerifySignature(method, key, expires, signature string) error {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expiration")
+	}
+	if time.Now().Unix() > exp {
+		return fmt.Errorf("signature expired")
+	}
+	expected := s.sign(method, key, exp)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// signedURL builds a URL for method on key that is valid for expire
+func (s *LocalStorage) signedURL(method, key string, expire time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	exp := time.Now().Add(expire).Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(exp, 10))
+	query.Set("signature", s.sign(method, key, exp))
+	return fmt.Sprintf("%s/%s?%s", s.baseURL, key, query.Encode()), nil
+}
+
+func (s *LocalStorage) sign(method, key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%d", method, key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path resolves key to a file path, rejecting keys that escape the root
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if key == "" || clean == "/" || strings.HasPrefix(clean, "/"+metaDir+"/") {
+		return "", fmt.Errorf("invalid object key: %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
+
+func (s *LocalStorage) metaPath(path string) string {
+	rel, _ := filepath.Rel(s.root, path)
+	return filepath.Join(s.root, metaDir, rel+".json")
+}
+
+func (s *LocalStorage) writeMeta(key string, meta localMeta) error {
+	path, _ := s.path(key)
+	metaPath := s.metaPath(path)
+	if err := os.MkdirAll(filepath.Dir(metaPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create metadata directory: %w", err)
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(metaPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write file metadata: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) objectInfo(key, path string, stat fs.FileInfo) *ObjectInfo {
+	info := &ObjectInfo{
+		Key:          key,
+		Size:         stat.Size(),
+		ContentType:  "application/octet-stream",
+		ETag:         fmt.Sprintf("\"%x-%x\"", stat.ModTime().UnixNano(), stat.Size()),
+		LastModified: stat.ModTime(),
+	}
+	if data, err := os.ReadFile(s.metaPath(path)); err == nil {
+		var meta localMeta
+		if json.Unmarshal(data, &meta) == nil && meta.ContentType != "" {
+			info.ContentType = meta.ContentType
+		}
+	}
+	return info
+}
+
+func (s *LocalStorage) wrapError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// limitedFile reads a section of a file and closes the file
+type limitedFile struct {
+	io.Reader
+	f *os.File
+}
+
+func (l *limitedFile) Close() error {
+	return l.f.Close()
+}
+
+// ctxReader aborts reads once the context is cancelled
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func readerWithContext(ctx context.Context, r io.Reader) io.Reader {
+	return &ctxReader{ctx: ctx, r: r}
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
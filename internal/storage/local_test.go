@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocalStorage(t *testing.T) *LocalStorage {
+	t.Helper()
+	store, err := NewLocalStorage(t.TempDir(), "http://localhost:8080/files/", []byte("secret"))
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	return store
+}
+
+func putString(t *testing.T, store *LocalStorage, key, body, contentType string) {
+	t.Helper()
+	if err := store.Put(context.Background(), key, strings.NewReader(body), -1, contentType); err != nil {
+		t.Fatalf("Put(%q): %v", key, err)
+	}
+}
+
+func readAll(t *testing.T, r io.ReadCloser) string {
+	t.Helper()
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return string(data)
+}
+
+func TestLocalStoragePutGet(t *testing.T) {
+	store := newTestLocalStorage(t)
+	putString(t, store, "images/a.png", "hello world", "image/png")
+
+	body, info, err := store.Get(context.Background(), "images/a.png")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got := readAll(t, body); got != "hello world" {
+		t.Errorf("Get body = %q, want %q", got, "hello world")
+	}
+	if info.Key != "images/a.png" || info.Size != 11 || info.ContentType != "image/png" {
+		t.Errorf("Get info = %+v", info)
+	}
+
+	// Overwriting replaces both the content and the content type
+	putString(t, store, "images/a.png", "bye", "image/jpeg")
+	body, info, err = store.Get(context.Background(), "images/a.png")
+	if err != nil {
+		t.Fatalf("Get after overwrite: %v", err)
+	}
+	if got := readAll(t, body); got != "bye" || info.ContentType != "image/jpeg" {
+		t.Errorf("Get after overwrite = %q (%s)", got, info.ContentType)
+	}
+}
+
+func TestLocalStorageGetRange(t *testing.T) {
+	store := newTestLocalStorage(t)
+	putString(t, store, "videos/v.mp4", "0123456789", "video/mp4")
+
+	tests := []struct {
+		offset, length int64
+		want           string
+	}{
+		{0, 4, "0123"},
+		{3, 4, "3456"},
+		{6, -1, "6789"},
+		{8, 10, "89"},
+	}
+	for _, tt := range tests {
+		body, err := store.GetRange(context.Background(), "videos/v.mp4", tt.offset, tt.length)
+		if err != nil {
+			t.Fatalf("GetRange(%d, %d): %v", tt.offset, tt.length, err)
+		}
+		if got := readAll(t, body); got != tt.want {
+			t.Errorf("GetRange(%d, %d) = %q, want %q", tt.offset, tt.length, got, tt.want)
+		}
+	}
+
+	if _, err := store.GetRange(context.Background(), "videos/missing.mp4", 0, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetRange on missing object: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalStorageHead(t *testing.T) {
+	store := newTestLocalStorage(t)
+	putString(t, store, "images/a.png", "abc", "image/png")
+
+	info, err := store.Head(context.Background(), "images/a.png")
+	if err != nil {
+		t.Fatalf("Head: %v", err)
+	}
+	if info.Size != 3 || info.ContentType != "image/png" || info.ETag == "" {
+		t.Errorf("Head = %+v", info)
+	}
+
+	if _, err := store.Head(context.Background(), "images/missing.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Head on missing object: err = %v, want ErrNotFound", err)
+	}
+	if _, err := store.Head(context.Background(), "images"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Head on directory: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalStorageList(t *testing.T) {
+	store := newTestLocalStorage(t)
+	putString(t, store, "uploads/1/a", "a", "application/octet-stream")
+	putString(t, store, "uploads/1/b", "bb", "application/octet-stream")
+	putString(t, store, "uploads/2/c", "c", "application/octet-stream")
+	putString(t, store, "images/d.png", "d", "image/png")
+
+	objects, err := store.List(context.Background(), "uploads/1/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var keys []string
+	for _, object := range objects {
+		keys = append(keys, object.Key)
+	}
+	if strings.Join(keys, ",") != "uploads/1/a,uploads/1/b" {
+		t.Errorf("List keys = %v", keys)
+	}
+
+	// Metadata sidecars are never listed as objects
+	objects, err = store.List(context.Background(), "")
+	if err != nil {
+		t.Fatalf("List all: %v", err)
+	}
+	if len(objects) != 4 {
+		t.Errorf("List all returned %d objects, want 4", len(objects))
+	}
+}
+
+func TestLocalStorageDelete(t *testing.T) {
+	store := newTestLocalStorage(t)
+	putString(t, store, "images/a.png", "abc", "image/png")
+
+	if err := store.Delete(context.Background(), "images/a.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Head(context.Background(), "images/a.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Head after Delete: err = %v, want ErrNotFound", err)
+	}
+	path, _ := store.path("images/a.png")
+	if _, err := os.Stat(store.metaPath(path)); !os.IsNotExist(err) {
+		t.Errorf("metadata still present after Delete: %v", err)
+	}
+
+	// Deleting a missing object is not an error
+	if err := store.Delete(context.Background(), "images/a.png"); err != nil {
+		t.Errorf("Delete of missing object: %v", err)
+	}
+}
+
+func TestLocalStorageRejectsEscapingKeys(t *testing.T) {
+	store := newTestLocalStorage(t)
+	for _, key := range []string{"", "/", ".meta/images/a.png.json", "../../.meta/a.json"} {
+		if err := store.Put(context.Background(), key, strings.NewReader("x"), -1, "text/plain"); err == nil {
+			t.Errorf("Put(%q) succeeded, want an error", key)
+		}
+	}
+}
+
+// signedParts splits a signed URL of store into its key, expiry and signature
+func signedParts(t *testing.T, store *LocalStorage, signed string) (key, expires, signature string) {
+	t.Helper()
+	if !strings.HasPrefix(signed, store.baseURL+"/") {
+		t.Fatalf("signed URL %q is not under %q", signed, store.baseURL)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("parse signed URL: %v", err)
+	}
+	key = strings.TrimPrefix(signed[:strings.Index(signed, "?")], store.baseURL+"/")
+	return key, u.Query().Get("expires"), u.Query().Get("signature")
+}
+
+func TestLocalStorageSignedURLs(t *testing.T) {
+	store := newTestLocalStorage(t)
+
+	get, err := store.PresignGet(context.Background(), "images/a.png", time.Minute)
+	if err != nil {
+		t.Fatalf("PresignGet: %v", err)
+	}
+	key, expires, signature := signedParts(t, store, get)
+	if key != "images/a.png" {
+		t.Errorf("signed key = %q", key)
+	}
+	if err := store.VerifySignature("GET", key, expires, signature); err != nil {
+		t.Errorf("VerifySignature of GET URL: %v", err)
+	}
+	if err := store.VerifySignature("PUT", key, expires, signature); err == nil {
+		t.Error("GET signature accepted for PUT")
+	}
+	if err := store.VerifySignature("GET", "images/b.png", expires, signature); err == nil {
+		t.Error("signature accepted for another key")
+	}
+	if err := store.VerifySignature("GET", key, expires+"0", signature); err == nil {
+		t.Error("signature accepted with a changed expiry")
+	}
+	if err := store.VerifySignature("GET", key, "soon", signature); err == nil {
+		t.Error("signature accepted with an invalid expiry")
+	}
+
+	put, err := store.PresignPut(context.Background(), "images/a.png", "image/png", time.Minute)
+	if err != nil {
+		t.Fatalf("PresignPut: %v", err)
+	}
+	key, expires, signature = signedParts(t, store, put)
+	if err := store.VerifySignature("PUT", key, expires, signature); err != nil {
+		t.Errorf("VerifySignature of PUT URL: %v", err)
+	}
+
+	other, err := NewLocalStorage(t.TempDir(), store.baseURL, []byte("other"))
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	if err := other.VerifySignature("PUT", key, expires, signature); err == nil {
+		t.Error("signature accepted by a backend with another secret")
+	}
+}
+
+func TestLocalStorageSignedURLExpires(t *testing.T) {
+	store := newTestLocalStorage(t)
+	past := time.Now().Add(-time.Minute).Unix()
+	signature := store.sign("GET", "images/a.png", past)
+	if err := store.VerifySignature("GET", "images/a.png", strconv.FormatInt(past, 10), signature); err == nil {
+		t.Error("expired signature accepted")
+	}
+}
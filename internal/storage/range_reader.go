@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// RangeReader reads an object through ranged requests so it can be seeked without
+// downloading the whole object, e.g. to serve Range requests with http.ServeContent
+// or to parse a container with random access.
+// A new ranged read is only started when the read position moves.
+type RangeReader struct {
+	ctx   context.Context
+	store Storage
+	info  *ObjectInfo
+	pos   int64
+	body  io.ReadCloser
+}
+
+// NewRangeReader creates a RangeReader for the object described by info
+func NewRangeReader(ctx context.Context, store Storage, info *ObjectInfo) *RangeReader {
+	return &RangeReader{ctx: ctx, store: store, info: info}
+}
+
+// Read reads from the current position, opening the object there if needed
+func (r *RangeReader) Read(p []byte) (int, error) {
+	if r.pos >= r.info.Size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, err := r.store.GetRange(r.ctx, r.info.Key, r.pos, -1)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+
+	n, err := r.body.Read(p)
+	r.pos += int64(n)
+	return n, err
+}
+
+// ReadAt reads len(p) bytes at off with a single ranged read, independent of the
+// read position (io.ReaderAt)
+func (r *RangeReader) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.info.Size {
+		return 0, io.EOF
+	}
+	length := min(int64(len(p)), r.info.Size-off)
+	body, err := r.store.GetRange(r.ctx, r.info.Key, off, length)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	n, err := io.ReadFull(body, p[:length])
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Seek moves the read position. The open read, if any, is dropped when the position changes.
+func (r *RangeReader) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = r.pos + offset
+	case io.SeekEnd:
+		pos = r.info.Size + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if pos < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	if pos != r.pos && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.pos = pos
+	return pos, nil
+}
+
+// Close closes the open read, if any
+func (r *RangeReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// S3Storage stores objects in an S3 bucket
+type S3Storage struct {
+	client   *s3.S3
+	uploader *s3manager.Uploader
+	bucket   string
+}
+
+// NewS3Storage creates a new S3 storage backend. Bodies are streamed in parts
+// of partSize bytes with up to concurrency parts in flight, so memory use is
+// bounded by partSize * concurrency regardless of the object size.
+func NewS3Storage(client *s3.S3, bucket string, partSize int64, concurrency int) *S3Storage {
+	uploader := s3manager.NewUploaderWithClient(client, func(u *s3manager.Uploader) {
+		if partSize >= s3manager.MinUploadPartSize {
+			u.PartSize = partSize
+		}
+		if concurrency > 0 {
+			u.Concurrency = concurrency
+		}
+	})
+	return &S3Storage{client: client, uploader: uploader, bucket: bucket}
+}
+
+// Put streams an object to S3, using a multipart upload for large bodies
+func (s *S3Storage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	_, err := s.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload to S3: %w", err)
+	}
+	return nil
+}
+
+// Get downloads an object from S3
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, nil, s.wrapError("get", err)
+	}
+
+	info := &ObjectInfo{
+		Key:          key,
+		Size:         aws.Int64Value(out.ContentLength),
+		ContentType:  aws.StringValue(out.ContentType),
+		ETag:         aws.StringValue(out.ETag),
+		LastModified: aws.TimeValue(out.LastModified),
+	}
+	return out.Body, info, nil
+}
+
+// GetRange downloads part of an object from S3 using a Range request
+func (s *S3Storage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	byteRange := fmt.Sprintf("bytes=%d-", offset)
+	if length >= 0 {
+		byteRange = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	}
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(byteRange),
+	})
+	if err != nil {
+		return nil, s.wrapError("get", err)
+	}
+	return out.Body, nil
+}
+
+// Delete deletes an object from S3
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete from S3: %w", err)
+	}
+	return nil
+}
+
+// Head retrieves object metadata from S3
+func (s *S3Storage) Head(ctx context.Context, key string) (*ObjectInfo, error) {
+	out, err := s.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, s.wrapError("head", err)
+	}
+
+	return &ObjectInfo{
+		Key:          key,
+		Size:         aws.Int64Value(out.ContentLength),
+		ContentType:  aws.StringValue(out.ContentType),
+		ETag:         aws.StringValue(out.ETag),
+		LastModified: aws.TimeValue(out.LastModified),
+	}, nil
+}
+
+// PresignGet generates a presigned GET URL for an S3 object
+func (s *S3Storage) PresignGet(ctx context.Context, key string, expire time.Duration) (string, error) {
+	req, _ := s.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	req.SetContext(ctx)
+
+	url, err := req.Presign(expire)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
+	}
+	return url, nil
+}
+
+// PresignPut generates a presigned PUT URL for uploading an S3 object
+func (s *S3Storage) PresignPut(ctx context.Context, key string, contentType string, expire time.Duration) (string, error) {
+	req, _ := s.client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	})
+	req.SetContext(ctx)
+
+	url, err := req.Presign(expire)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
+	}
+	return url, nil
+}
+
+// List lists all objects under the given prefix
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	err := s.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			objects = append(objects, ObjectInfo{
+				Key:          aws.StringValue(obj.Key),
+				Size:         aws.Int64Value(obj.Size),
+				ETag:         aws.StringValue(obj.ETag),
+				LastModified: aws.TimeValue(obj.LastModified),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list S3 objects: %w", err)
+	}
+	return objects, nil
+}
+
+// Bucket returns the S3 bucket name
+func (s *S3Storage) Bucket() string {
+	return s.bucket
+}
+
+// wrapError maps S3 "not found" responses to ErrNotFound
+func (s *S3Storage) wrapError(op string, err error) error {
+	var reqErr awserr.RequestFailure
+	if errors.As(err, &reqErr) && reqErr.StatusCode() == http.StatusNotFound {
+		return ErrNotFound
+	}
+	var aErr awserr.Error
+	if errors.As(err, &aErr) && aErr.Code() == s3.ErrCodeNoSuchKey {
+		return ErrNotFound
+	}
+	return fmt.Errorf("failed to %s S3 object: %w", op, err)
+}
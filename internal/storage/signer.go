@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/cloudfront/sign"
+)
+
+// URLSigner hands out time-limited download URLs for stored objects
+type URLSigner interface {
+	SignURL(ctx context.Context, key string, expire time.Duration) (string, error)
+}
+
+// PresignSigner signs URLs with the presigning of the storage backend itself
+type PresignSigner struct {
+	store Storage
+}
+
+// NewPresignSigner creates a signer that delegates to store.PresignGet
+func NewPresignSigner(store Storage) *PresignSigner {
+	return &PresignSigner{store: store}
+}
+
+// SignURL returns a presigned URL of the storage backend
+func (s *PresignSigner) SignURL(ctx context.Context, key string, expire time.Duration) (string, error) {
+	return s.store.PresignGet(ctx, key, expire)
+}
+
+// CloudFrontSigner signs CDN URLs with a CloudFront canned policy, so downloads
+// are served by the CDN instead of directly from the bucket. Keys under the output
+// prefix can be served from their own domain.
+type CloudFrontSigner struct {
+	baseURL       string
+	outputBaseURL string
+	outputPrefix  string
+	signer        *sign.URLSigner
+}
+
+// NewCloudFrontSigner creates a CloudFront signer. domain and outputDomain may be
+// given with or without scheme; an empty outputDomain serves outputs from domain.
+func NewCloudFrontSigner(domain, outputDomain, outputPrefix, keyPairID string, privateKey *rsa.PrivateKey) *CloudFrontSigner {
+	if outputDomain == "" {
+		outputDomain = domain
+	}
+	return &CloudFrontSigner{
+		baseURL:       baseURL(domain),
+		outputBaseURL: baseURL(outputDomain),
+		outputPrefix:  strings.Trim(outputPrefix, "/") + "/",
+		signer:        sign.NewURLSigner(keyPairID, privateKey),
+	}
+}
+
+// SignURL returns a CloudFront URL for key that expires after expire
+func (s *CloudFrontSigner) SignURL(ctx context.Context, key string, expire time.Duration) (string, error) {
+	base := s.baseURL
+	if strings.HasPrefix(key, s.outputPrefix) {
+		base = s.outputBaseURL
+	}
+
+	rawURL := base + "/" + (&url.URL{Path: key}).EscapedPath()
+	signed, err := s.signer.Sign(rawURL, time.Now().Add(expire))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign CDN URL: %w", err)
+	}
+	return signed, nil
+}
+
+func baseURL(domain string) string {
+	domain = strings.TrimRight(domain, "/")
+	if !strings.Contains(domain, "://") {
+		domain = "https://" + domain
+	}
+	return domain
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned when the requested object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// Storage is the interface implemented by every object storage backend
+type Storage interface {
+	// Put stores the body under key. size may be -1 when unknown.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens the object for reading. The caller must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// GetRange opens length bytes of the object starting at offset; a negative length
+	// reads to the end. The caller must close the reader.
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	// Delete removes the object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// Head returns the object metadata without its content
+	Head(ctx context.Context, key string) (*ObjectInfo, error)
+	// PresignGet returns a time-limited URL for downloading the object
+	PresignGet(ctx context.Context, key string, expire time.Duration) (string, error)
+	// PresignPut returns a time-limited URL for uploading the object directly.
+	// The client must send the same Content-Type header.
+	PresignPut(ctx context.Context, key string, contentType string, expire time.Duration) (string, error)
+	// List returns all objects whose key starts with prefix
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// Bucket returns the name recorded on models for objects in this backend
+	Bucket() string
+}
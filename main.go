@@ -9,9 +9,11 @@ import (
 	"screensaver-ad-backend/config"
 	_ "screensaver-ad-backend/docs" // Import generated docs
 	"screensaver-ad-backend/internal/controllers"
+	"screensaver-ad-backend/internal/jobs"
 	"screensaver-ad-backend/internal/models"
 	"screensaver-ad-backend/internal/repository"
 	"screensaver-ad-backend/internal/services"
+	"screensaver-ad-backend/internal/storage"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -57,6 +59,27 @@ func main() {
 		log.Printf("Warning: Failed to initialize S3: %v", err)
 	}
 
+	config.InitUpload()
+	config.InitReconcile()
+	config.InitRetention()
+	config.InitMedia()
+	config.InitTaskRetry()
+	config.InitTaskTimeout()
+
+	// Initialize storage backend (falls back to local disk without S3)
+	if err := config.InitStorage(); err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	if err := config.InitURLSigner(); err != nil {
+		log.Fatalf("Failed to initialize URL signer: %v", err)
+	}
+	if err := config.InitModerator(); err != nil {
+		log.Fatalf("Failed to initialize moderator: %v", err)
+	}
+	if err := config.InitDispatch(); err != nil {
+		log.Fatalf("Failed to initialize dispatch: %v", err)
+	}
+
 	// Auto-migrate database models
 	db := config.GetDB()
 	if err := db.AutoMigrate(models.Models()...); err != nil {
@@ -65,31 +88,57 @@ func main() {
 	log.Println("Database migration completed successfully")
 
 	// Initialize layers
+	storageService := services.NewStorageService(config.GetStorage(), config.GetURLSigner())
+
 	assetRepo := repository.NewAssetRepository(db)
-	assetService := services.NewAssetService(assetRepo)
+	assetService := services.NewAssetService(assetRepo, storageService, config.GetModerator())
 	assetController := controllers.NewAssetController(assetService)
 
-	s3Service := services.NewS3Service()
-
 	templateRepo := repository.NewTemplateRepository(db)
-	templateService := services.NewTemplateService(templateRepo)
-	templateController := controllers.NewTemplateController(templateService, s3Service)
+	templateService := services.NewTemplateService(templateRepo, storageService)
+	templateController := controllers.NewTemplateController(templateService, storageService)
 
 	taskRepo := repository.NewTaskRepository(db)
-	taskService := services.NewTaskService(taskRepo, assetRepo)
+	taskService := services.NewTaskService(taskRepo, assetRepo, templateRepo, storageService, config.GetDispatchTransport())
 	taskController := controllers.NewTaskController(taskService)
 
 	webhookController := controllers.NewWebhookController(taskService, assetService)
 
+	uploadSessionRepo := repository.NewUploadSessionRepository(db)
+	resumableUploadService := services.NewResumableUploadService(uploadSessionRepo, assetService, storageService)
+	tusController := controllers.NewTusController(resumableUploadService)
+
+	reconcileService := services.NewReconcileService(assetRepo, templateRepo, storageService)
+
+	// CLI subcommands, e.g. `go run . reconcile -repair`
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:], commandServices{reconcile: reconcileService}); err != nil {
+			log.Fatalf("Command %s failed: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	// Background jobs
+	jobs.Every("process-uploads", config.GetMediaConfig().ProcessInterval, assetService.ProcessUploads)
+	jobs.Every("expire-pending-uploads", config.GetUploadConfig().PendingCleanupInterval, assetService.ExpirePendingUploads)
+	jobs.Every("expire-resumable-uploads", config.GetUploadConfig().PendingCleanupInterval, resumableUploadService.ExpireUploads)
+	jobs.Every("purge-deleted-assets", config.GetRetentionConfig().PurgeInterval, assetService.PurgeDeletedAssets)
+	jobs.Every("dispatch-pending-tasks", config.GetDispatchConfig().RetryInterval, taskService.DispatchPendingTasks)
+	jobs.Every("retry-failed-tasks", config.GetTaskRetryConfig().Interval, taskService.RetryDueTasks)
+	jobs.Every("sweep-timed-out-tasks", config.GetTaskTimeoutConfig().SweepInterval, taskService.SweepTimedOutTasks)
+	jobs.Every("reconcile-storage", config.GetReconcileConfig().Interval, reconcileService.RunScheduled)
+
 	// Setup Gin router
 	router := gin.Default()
 
 	// CORS middleware
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		if c.Request.Method == "OPTIONS" {
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Range, If-Range, Tus-Resumable, Upload-Length, Upload-Metadata, Upload-Offset")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Accept-Ranges, Content-Range, Content-Length, Content-Disposition, ETag, Location, Tus-Resumable, Tus-Version, Tus-Extension, Tus-Max-Size, Upload-Offset, Upload-Length, Upload-Expires, Upload-Asset-Id")
+		// Answer CORS preflights here; other OPTIONS requests (tus discovery) reach their routes
+		if c.Request.Method == "OPTIONS" && c.GetHeader("Access-Control-Request-Method") != "" {
 			c.AbortWithStatus(204)
 			return
 		}
@@ -111,10 +160,20 @@ func main() {
 		{
 			assets.GET("", assetController.ListAssets)
 			assets.POST("", assetController.CreateAsset)
+			assets.POST("/uploads", assetController.InitiateUpload)
+			assets.GET("/trash", assetController.ListTrash)
 			assets.GET("/:id", assetController.GetAsset)
 			assets.GET("/:id/url", assetController.GetAssetURL)
+			assets.GET("/:id/content", assetController.StreamAsset)
+			assets.GET("/:id/similar", assetController.GetSimilarAssets)
+			assets.GET("/:id/tasks", taskController.ListAssetTasks)
+			assets.HEAD("/:id/content", assetController.StreamAsset)
 			assets.PUT("/:id", assetController.UpdateAsset)
 			assets.PATCH("/:id/status", assetController.UpdateAssetStatus)
+			assets.PUT("/:id/focal-point", assetController.SetFocalPoint)
+			assets.PUT("/:id/review", assetController.ReviewAsset)
+			assets.POST("/:id/complete", assetController.CompleteUpload)
+			assets.POST("/:id/restore", assetController.RestoreAsset)
 			assets.DELETE("/:id", assetController.DeleteAsset)
 		}
 
@@ -122,17 +181,45 @@ func main() {
 		{
 			templates.GET("", templateController.ListTemplates)
 			templates.POST("", templateController.UploadTemplate)
+			templates.PUT("/:id/requirements", templateController.UpdateRequirements)
+			templates.PUT("/:id/retry-policy", templateController.UpdateRetryPolicy)
+			templates.PUT("/:id/timeout", templateController.UpdateTaskTimeout)
+			templates.GET("/:id/tasks", taskController.ListTemplateTasks)
 		}
 
 		tasks := api.Group("/tasks")
 		{
+			tasks.GET("", taskController.ListTasks)
 			tasks.POST("", taskController.CreateTask)
+			tasks.POST("/batch", taskController.CreateTaskBatch)
+			tasks.GET("/batch/:id", taskController.GetTaskBatch)
+			tasks.GET("/dead-letter", taskController.ListDeadLetterTasks)
+			tasks.GET("/:id", taskController.GetTask)
+			tasks.POST("/:id/cancel", taskController.CancelTask)
+			tasks.POST("/:id/rerun", taskController.RerunTask)
+			tasks.POST("/:id/requeue", taskController.RequeueTask)
+		}
+
+		tus := api.Group("/uploads/tus", tusController.Middleware)
+		{
+			tus.OPTIONS("", tusController.Options)
+			tus.POST("", tusController.CreateUpload)
+			tus.HEAD("/:id", tusController.GetOffset)
+			tus.PATCH("/:id", tusController.AppendChunk)
+			tus.DELETE("/:id", tusController.TerminateUpload)
 		}
 
 		// Webhook endpoint
 		api.POST("/webhook", webhookController.HandleWebhook)
 	}
 
+	// Signed object URLs for the local storage backend
+	if local, ok := config.GetStorage().(*storage.LocalStorage); ok {
+		storageController := controllers.NewStorageController(local)
+		router.GET("/storage/*key", storageController.GetObject)
+		router.PUT("/storage/*key", storageController.PutObject)
+	}
+
 	// Swagger documentation route
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 